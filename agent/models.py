@@ -46,4 +46,12 @@ class CallsFinishBody(BaseModel):
     user_id: str
     is_available: bool
     deal_price: int
-    remarks: str
\ No newline at end of file
+    remarks: str
+    # Call result (completed, unavailable, no_answer, voicemail, failed); derived from is_available when omitted
+    status: Optional[str] = None
+
+class CallStatusBody(BaseModel):
+    user_id: str
+    # Call progress: ringing, in_progress or cancelled
+    status: str
+    reason: Optional[str] = None
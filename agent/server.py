@@ -1,17 +1,19 @@
 """
 FastAPI RESTful API server.
 """
+import asyncio
 import json
 import os
-from fastapi import FastAPI, Request
+from fastapi import BackgroundTasks, FastAPI, Header, Request
 from fastapi.middleware.cors import CORSMiddleware
 import time
-from typing import Dict, Any, List
+from typing import Dict, Any, List, Optional, Tuple
 from dotenv import load_dotenv
 from elevenlabs import ElevenLabs
+from elevenlabs.core.api_error import ApiError
 import requests
-from models import CallsFinishBody, DealerQuery, WebhookPayload, WebhookType
-from utils import verify_elevenlabs_signature
+from models import CallsFinishBody, CallStatusBody, DealerQuery, WebhookPayload, WebhookType
+from utils import sign_backend_request, verify_elevenlabs_signature
 from process_transcript import process_transcript
 
 # Load environment variables from .env file
@@ -24,6 +26,18 @@ ELEVENLABS_NEGOTIATING_AGENT_ID = os.getenv("ELEVENLABS_NEGOTIATING_AGENT_ID")
 ELEVENLABS_AGENT_PHONE_NUMBER_ID = os.getenv("ELEVENLABS_AGENT_PHONE_NUMBER_ID")
 ELEVENLABS_WEBHOOK_SECRET = os.getenv("ELEVENLABS_WEBHOOK_SECRET")
 BACKEND_URL = os.getenv("BACKEND_URL")
+BACKEND_WEBHOOK_SECRET = os.getenv("BACKEND_WEBHOOK_SECRET")
+
+# How long an accepted batch is remembered by its Idempotency-Key
+IDEMPOTENCY_TTL_SECONDS = int(os.getenv("IDEMPOTENCY_TTL_SECONDS", str(24 * 60 * 60)))
+# How long a callback to the backend may take
+BACKEND_TIMEOUT_SECONDS = float(os.getenv("BACKEND_TIMEOUT_SECONDS", "10"))
+
+# /calls/init responses by Idempotency-Key, with the time they were stored: accepted batches,
+# and failed ones whose dealers may have been dialed anyway
+init_results: Dict[str, Tuple[float, Dict[str, Any]]] = {}
+# One lock per key, so a resend that arrives while the first request is running waits for its outcome
+init_locks: Dict[str, asyncio.Lock] = {}
 
 
 # Initialize ElevenLabs client
@@ -79,8 +93,56 @@ async def health() -> Dict[str, str]:
 
 
 @app.post("/calls/init")
-async def call_dealers(queries: List[DealerQuery]) -> Dict[str, Any]:
-    """Call a list of dealers using ElevenLabs batch calling API."""
+async def call_dealers(
+    queries: List[DealerQuery],
+    background_tasks: BackgroundTasks,
+    idempotency_key: Optional[str] = Header(default=None),
+) -> Dict[str, Any]:
+    """Call a list of dealers, at most once per Idempotency-Key.
+
+    The backend resends a batch with the same key when it could not record the
+    outcome, so a batch that may have dialed anyone is answered from memory
+    instead of dialing again. The calls are reported as ringing to the backend
+    after the response is sent, so a slow backend never holds up the batch.
+    """
+    if not idempotency_key:
+        result, dialed = await asyncio.to_thread(dial_dealers, queries)
+        report_ringing(background_tasks, dialed)
+        return result
+
+    lock = init_locks.setdefault(idempotency_key, asyncio.Lock())
+    async with lock:
+        forget_expired_results()
+        cached = init_results.get(idempotency_key)
+        if cached is not None:
+            print(f"Batch {idempotency_key} was already attempted; not dialing again")
+            return cached[1]
+
+        result, dialed = await asyncio.to_thread(dial_dealers, queries)
+        # Only a batch ElevenLabs refused outright dialed nobody, so only that one may be dialed again on retry
+        if result.get("may_have_dialed", True):
+            init_results[idempotency_key] = (time.time(), result)
+        report_ringing(background_tasks, dialed)
+        return result
+
+
+def forget_expired_results() -> None:
+    """Drop batches accepted longer than IDEMPOTENCY_TTL_SECONDS ago."""
+    cutoff = time.time() - IDEMPOTENCY_TTL_SECONDS
+    for key, (stored_at, _) in list(init_results.items()):
+        if stored_at < cutoff:
+            del init_results[key]
+    # Locks of batches not remembered and of expired results, unless a request is using them
+    for key, lock in list(init_locks.items()):
+        if key not in init_results and not lock.locked():
+            del init_locks[key]
+
+
+def dial_dealers(queries: List[DealerQuery]) -> Tuple[Dict[str, Any], List[str]]:
+    """Call a list of dealers using ElevenLabs batch calling API.
+
+    Returns the /calls/init response and the call IDs ElevenLabs is now dialing.
+    """
     # Transform DealerQuery list to ElevenLabs format
     recipients = []
     for query in queries:
@@ -114,17 +176,62 @@ async def call_dealers(queries: List[DealerQuery]) -> Dict[str, Any]:
             agent_phone_number_id=ELEVENLABS_AGENT_PHONE_NUMBER_ID,
             recipients=recipients
         )
-        
+    except ApiError as e:
+        # ElevenLabs answered and refused the batch, so nobody was dialed
         return {
-            "status": "success",
-            "elevenlabs_response": response,
-            "recipients_count": len(recipients)
-        }
+            "status": "error",
+            "error": f"ElevenLabs API error: {str(e)}",
+            "may_have_dialed": False
+        }, []
     except Exception as e:
+        # No answer from ElevenLabs: the batch may have been created, so it must not be sent again
         return {
             "status": "error",
-            "error": f"ElevenLabs API error: {str(e)}"
-        }
+            "error": f"ElevenLabs request failed: {str(e)}",
+            "may_have_dialed": True
+        }, []
+
+    # ElevenLabs now dials the recipients
+    return {
+        "status": "success",
+        "elevenlabs_response": response,
+        "recipients_count": len(recipients)
+    }, [query.user_id for query in queries]
+
+
+def report_ringing(background_tasks: BackgroundTasks, call_ids: List[str]) -> None:
+    """Report the calls of an accepted batch as ringing once the response has been sent."""
+    for call_id in call_ids:
+        background_tasks.add_task(report_call_status, call_id, "ringing", "batch accepted by ElevenLabs")
+
+
+def post_backend(path: str, body: dict) -> requests.Response:
+    """POST a callback to the backend, signed with BACKEND_WEBHOOK_SECRET."""
+    payload = json.dumps(body).encode("utf-8")
+    headers = {"Content-Type": "application/json"}
+    if BACKEND_WEBHOOK_SECRET:
+        headers.update(sign_backend_request(payload, BACKEND_WEBHOOK_SECRET))
+    return requests.post(f"{BACKEND_URL}{path}", data=payload, headers=headers, timeout=BACKEND_TIMEOUT_SECONDS)
+
+
+def post_call_finish(body: CallsFinishBody) -> requests.Response:
+    """Send a call result to the backend."""
+    return post_backend("/api/calls/finish", body.model_dump(exclude_none=True))
+
+
+def report_call_status(call_id: str, status: str, reason: str) -> None:
+    """Report a call's progress (ringing, in_progress or cancelled) to the backend.
+
+    Progress is informational, so a failed report is logged and the call carries on.
+    """
+    body = CallStatusBody(user_id=call_id, status=status, reason=reason)
+    try:
+        response = post_backend("/api/calls/status", body.model_dump())
+        if response.status_code != 200:
+            print(f"Backend refused {status} for call {call_id}: {response.status_code}")
+    except requests.RequestException as e:
+        print(f"Failed to report {status} for call {call_id}: {e}")
+
 
 @app.post("/calls/webhook")
 async def calls_webhook(request: Request):
@@ -139,6 +246,9 @@ async def calls_webhook(request: Request):
     typed_payload = WebhookPayload.model_validate_json(payload.decode('utf-8'))
     print(f"webhook type: {typed_payload.type}")
     if typed_payload.type == WebhookType.POST_CALL_TRANSCRIPTION:
+        # A transcript means the dealer picked up; summarizing it takes a while
+        report_call_status(typed_payload.data.user_id, "in_progress", "dealer answered")
+
         # call backend at /calls/finish
         transcript_summary = process_transcript(typed_payload.data.transcript)
         calls_finish_body = CallsFinishBody(
@@ -148,7 +258,7 @@ async def calls_webhook(request: Request):
             remarks=transcript_summary.remarks
         )
         print(f"Calling backend with body: {calls_finish_body.model_dump()}")
-        response = requests.post(f"{BACKEND_URL}/api/calls/finish", json=calls_finish_body.model_dump())
+        response = post_call_finish(calls_finish_body)
         if response.status_code != 200:
             return {"status": "error", "message": "Failed to call backend"}
         return {"status": "success", "message": "Backend called successfully"}
@@ -158,9 +268,10 @@ async def calls_webhook(request: Request):
             user_id=typed_payload.data.user_id,
             is_available=False,
             deal_price=0,
-            remarks="Call initiation failed"
+            remarks="Call initiation failed",
+            status="failed"
         )
-        response = requests.post(f"{BACKEND_URL}/api/calls/finish", json=calls_finish_body.model_dump())
+        response = post_call_finish(calls_finish_body)
         if response.status_code != 200:
             return {"status": "error", "message": "Failed to call backend"}
         return {"status": "success", "message": "Backend called successfully"}
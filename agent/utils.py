@@ -4,7 +4,7 @@ Utility functions for the agent service.
 import hmac
 import time
 from hashlib import sha256
-from typing import Optional
+from typing import Dict, Optional
 
 
 def verify_elevenlabs_signature(
@@ -61,3 +61,26 @@ def verify_elevenlabs_signature(
     
     return True
 
+
+
+def sign_backend_request(payload: bytes, backend_secret: str) -> Dict[str, str]:
+    """
+    Sign a callback to the backend's /api/calls/finish endpoint.
+
+    Args:
+        payload: The exact request body that will be sent
+        backend_secret: One of the secrets in the backend's AGENT_WEBHOOK_SECRETS
+
+    Returns:
+        The X-Signature-Timestamp and X-Signature headers to send with the body
+    """
+    timestamp_str = str(int(time.time()))
+    mac = hmac.new(
+        key=backend_secret.encode("utf-8"),
+        msg=timestamp_str.encode("utf-8") + b"." + payload,
+        digestmod=sha256,
+    )
+    return {
+        "X-Signature-Timestamp": timestamp_str,
+        "X-Signature": "v1=" + mac.hexdigest(),
+    }
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"hackutd2025/backend/internal/database"
+)
+
+const usage = `Usage: migrate <command>
+
+Commands:
+  up          Apply every pending migration
+  down [n]    Roll back the last n applied migrations (default 1)
+  status      List migrations and whether they are applied
+
+DATABASE_URL must point at the target database.`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+
+	dbURL := os.Getenv("DATABASE_URL")
+	if dbURL == "" {
+		log.Fatal("DATABASE_URL is required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if err := database.InitDB(ctx, dbURL, database.DefaultPoolSettings); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer database.CloseDB()
+
+	switch os.Args[1] {
+	case "up":
+		applied, err := database.MigrateUp(ctx)
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		log.Printf("Applied %d migration(s)", len(applied))
+
+	case "down":
+		steps := 1
+		if len(os.Args) > 2 {
+			n, err := strconv.Atoi(os.Args[2])
+			if err != nil || n <= 0 {
+				log.Fatalf("down expects a positive number of steps, got %q", os.Args[2])
+			}
+			steps = n
+		}
+
+		rolledBack, err := database.MigrateDown(ctx, steps)
+		if err != nil {
+			log.Fatalf("Rollback failed: %v", err)
+		}
+		log.Printf("Rolled back %d migration(s)", len(rolledBack))
+
+	case "status":
+		states, err := database.GetMigrationStatus(ctx)
+		if err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+		for _, state := range states {
+			appliedAt := "pending"
+			if state.Applied {
+				appliedAt = "applied " + state.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d  %-32s  %s\n", state.Version, state.Name, appliedAt)
+		}
+
+	default:
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// backgroundWorker is work that outlives the request that started it, such as listing recordings
+type backgroundWorker interface {
+	Wait()
+}
+
+// backgroundWorkers are stopped in registration order during shutdown
+type backgroundWorkers struct {
+	names   []string
+	workers []backgroundWorker
+}
+
+// Add registers a worker to wait for during shutdown
+// Register producers before the workers they feed, so their output is drained too
+func (b *backgroundWorkers) Add(name string, worker backgroundWorker) {
+	b.names = append(b.names, name)
+	b.workers = append(b.workers, worker)
+}
+
+// Wait waits for each worker in turn, giving up when ctx ends
+func (b *backgroundWorkers) Wait(ctx context.Context) error {
+	for i, worker := range b.workers {
+		done := make(chan struct{})
+		go func() {
+			worker.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+			slog.InfoContext(ctx, "stopped background worker", slog.String("worker", b.names[i]))
+		case <-ctx.Done():
+			return fmt.Errorf("gave up waiting for %s: %w", b.names[i], ctx.Err())
+		}
+	}
+	return nil
+}
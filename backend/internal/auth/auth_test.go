@@ -0,0 +1,302 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com/"
+	testAudience = "https://api.example.com"
+)
+
+// signingKey is an RSA key published in the test JWKS under kid
+type signingKey struct {
+	kid     string
+	private *rsa.PrivateKey
+}
+
+func newSigningKey(t *testing.T, kid string) signingKey {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return signingKey{kid: kid, private: private}
+}
+
+// sign issues a token for sub signed with the key, applying edit to the default claims first
+func (k signingKey) sign(t *testing.T, sub string, edit func(*jwt.RegisteredClaims)) string {
+	t.Helper()
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		Issuer:    testIssuer,
+		Subject:   sub,
+		Audience:  jwt.ClaimStrings{testAudience},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+	}
+	if edit != nil {
+		edit(&claims)
+	}
+	return k.signClaims(t, claims)
+}
+
+// signClaims issues a token carrying exactly claims, signed with the key
+func (k signingKey) signClaims(t *testing.T, claims jwt.Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = k.kid
+	signed, err := token.SignedString(k.private)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+// jwksServer is a local stand-in for an identity provider's JWKS endpoint
+type jwksServer struct {
+	*httptest.Server
+
+	mu   sync.Mutex
+	keys []signingKey
+}
+
+func newJWKSServer(t *testing.T, keys ...signingKey) *jwksServer {
+	t.Helper()
+	s := &jwksServer{keys: keys}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveJWKS))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// publish replaces the published key set, as an identity provider does when it rotates keys
+func (s *jwksServer) publish(keys ...signingKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func (s *jwksServer) serveJWKS(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jwks := make([]map[string]string, 0, len(s.keys))
+	for _, key := range s.keys {
+		public := key.private.PublicKey
+		jwks = append(jwks, map[string]string{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": key.kid,
+			"n":   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"keys": jwks})
+}
+
+// newTestVerifier creates a verifier backed by the JWKS at server
+func newTestVerifier(t *testing.T, server *jwksServer) *Verifier {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	keyfunc, err := NewJWKSKeyfunc(ctx, JWKSOptions{
+		URL:                server.URL,
+		RefreshInterval:    time.Hour,
+		UnknownKIDInterval: time.Millisecond,
+		Timeout:            5 * time.Second,
+		Client:             server.Client(),
+	})
+	if err != nil {
+		t.Fatalf("NewJWKSKeyfunc: %v", err)
+	}
+	return NewVerifier(keyfunc, testIssuer, testAudience)
+}
+
+func TestVerifierAcceptsValidToken(t *testing.T) {
+	key := newSigningKey(t, "key-1")
+	verifier := newTestVerifier(t, newJWKSServer(t, key))
+
+	claims, err := verifier.Verify(key.sign(t, "auth0|alice", nil))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if claims.Subject != "auth0|alice" {
+		t.Errorf("subject = %q, want auth0|alice", claims.Subject)
+	}
+}
+
+func TestVerifierRejectsExpiredToken(t *testing.T) {
+	key := newSigningKey(t, "key-1")
+	verifier := newTestVerifier(t, newJWKSServer(t, key))
+
+	token := key.sign(t, "auth0|alice", func(claims *jwt.RegisteredClaims) {
+		claims.IssuedAt = jwt.NewNumericDate(time.Now().Add(-2 * time.Hour))
+		claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+	})
+	if _, err := verifier.Verify(token); !errors.Is(err, jwt.ErrTokenExpired) {
+		t.Fatalf("Verify error = %v, want %v", err, jwt.ErrTokenExpired)
+	}
+}
+
+func TestVerifierRejectsWrongAudience(t *testing.T) {
+	key := newSigningKey(t, "key-1")
+	verifier := newTestVerifier(t, newJWKSServer(t, key))
+
+	token := key.sign(t, "auth0|alice", func(claims *jwt.RegisteredClaims) {
+		claims.Audience = jwt.ClaimStrings{"https://other.example.com"}
+	})
+	if _, err := verifier.Verify(token); !errors.Is(err, jwt.ErrTokenInvalidAudience) {
+		t.Fatalf("Verify error = %v, want %v", err, jwt.ErrTokenInvalidAudience)
+	}
+}
+
+func TestVerifierRejectsUnknownKID(t *testing.T) {
+	published := newSigningKey(t, "key-1")
+	verifier := newTestVerifier(t, newJWKSServer(t, published))
+
+	// Signed with a key the identity provider never published
+	unpublished := newSigningKey(t, "key-unknown")
+	if _, err := verifier.Verify(unpublished.sign(t, "auth0|mallory", nil)); err == nil {
+		t.Fatal("Verify accepted a token signed with an unpublished key")
+	}
+}
+
+func TestVerifierPicksUpRotatedKey(t *testing.T) {
+	oldKey := newSigningKey(t, "key-1")
+	server := newJWKSServer(t, oldKey)
+	verifier := newTestVerifier(t, server)
+
+	if _, err := verifier.Verify(oldKey.sign(t, "auth0|alice", nil)); err != nil {
+		t.Fatalf("Verify with the original key: %v", err)
+	}
+
+	// Rotate: the new key is published and the old one withdrawn before the scheduled refresh
+	newKey := newSigningKey(t, "key-2")
+	server.publish(newKey)
+
+	// A token with the new kid triggers a refresh of the cached key set
+	if _, err := verifier.Verify(newKey.sign(t, "auth0|alice", nil)); err != nil {
+		t.Fatalf("Verify with the rotated key: %v", err)
+	}
+}
+
+func TestVerifierReadsCustomClaims(t *testing.T) {
+	key := newSigningKey(t, "key-1")
+	verifier := newTestVerifier(t, newJWKSServer(t, key))
+
+	now := time.Now()
+	claims, err := verifier.Verify(key.signClaims(t, jwt.MapClaims{
+		"iss":                      testIssuer,
+		"sub":                      "auth0|alice",
+		"aud":                      testAudience,
+		"iat":                      now.Unix(),
+		"exp":                      now.Add(time.Hour).Unix(),
+		"scope":                    "calls:write tier:enterprise",
+		"https://example.com/tier": "pro",
+		"https://example.com/n":    3,
+	}))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if claims.Subject != "auth0|alice" || claims.Scope != "calls:write tier:enterprise" {
+		t.Errorf("claims = %+v, want the registered claims and scope decoded", claims)
+	}
+
+	tests := []struct {
+		claim string
+		want  string
+	}{
+		{"https://example.com/tier", "pro"},
+		{"https://example.com/n", ""},
+		{"https://example.com/missing", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := claims.Custom(tt.claim); got != tt.want {
+			t.Errorf("Custom(%q) = %q, want %q", tt.claim, got, tt.want)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	key := newSigningKey(t, "key-1")
+	verifier := newTestVerifier(t, newJWKSServer(t, key))
+
+	var gotUserID, gotTier string
+	handler := Middleware(verifier, "https://example.com/tier")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUserID, _ = UserID(r.Context())
+		gotTier = Tier(r.Context())
+	}))
+
+	now := time.Now()
+	tiered := key.signClaims(t, jwt.MapClaims{
+		"iss":                      testIssuer,
+		"sub":                      "auth0|bob",
+		"aud":                      testAudience,
+		"iat":                      now.Unix(),
+		"exp":                      now.Add(time.Hour).Unix(),
+		"scope":                    "tier:enterprise",
+		"https://example.com/tier": "pro",
+	})
+	// A tier scope is something a client can ask for, so it must not grant a tier
+	scoped := key.signClaims(t, jwt.MapClaims{
+		"iss":   testIssuer,
+		"sub":   "auth0|mallory",
+		"aud":   testAudience,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"scope": "tier:enterprise",
+	})
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantUserID    string
+		wantTier      string
+	}{
+		{"valid", "Bearer " + key.sign(t, "auth0|alice", nil), http.StatusOK, "auth0|alice", ""},
+		{"tier claim", "Bearer " + tiered, http.StatusOK, "auth0|bob", "pro"},
+		{"tier scope only", "Bearer " + scoped, http.StatusOK, "auth0|mallory", ""},
+		{"missing", "", http.StatusUnauthorized, "", ""},
+		{"not bearer", "Basic YWxpY2U6c2VjcmV0", http.StatusUnauthorized, "", ""},
+		{"garbage", "Bearer not-a-jwt", http.StatusUnauthorized, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotUserID, gotTier = "", ""
+			req := httptest.NewRequest(http.MethodGet, "/api/calls", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if gotUserID != tt.wantUserID {
+				t.Errorf("user ID = %q, want %q", gotUserID, tt.wantUserID)
+			}
+			if gotTier != tt.wantTier {
+				t.Errorf("tier = %q, want %q", gotTier, tt.wantTier)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"hackutd2025/backend/internal/logging"
+)
+
+type userIDKey struct{}
+
+type tierKey struct{}
+
+// WithUserID returns a copy of ctx carrying the authenticated user ID
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// UserID returns the authenticated user ID carried by ctx, and whether the request was authenticated
+func UserID(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(userIDKey{}).(string)
+	return userID, ok && userID != ""
+}
+
+// WithTier returns a copy of ctx carrying the caller's rate limit tier
+func WithTier(ctx context.Context, tier string) context.Context {
+	return context.WithValue(ctx, tierKey{}, tier)
+}
+
+// Tier returns the caller's rate limit tier carried by ctx, or "" if none was granted
+func Tier(ctx context.Context) string {
+	tier, _ := ctx.Value(tierKey{}).(string)
+	return tier
+}
+
+// Middleware rejects requests without a valid bearer token with 401
+// and carries the token's sub claim on the request context as the user ID, along with the tier named by tierClaim
+// The tier claim should be a namespaced custom claim set by the identity provider, never one a client can request
+func Middleware(verifier *Verifier, tierClaim string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, err := bearerToken(r)
+			if err == nil {
+				var claims *Claims
+				claims, err = verifier.Verify(token)
+				if err == nil {
+					ctx := WithTier(WithUserID(r.Context(), claims.Subject), claims.Custom(tierClaim))
+					next.ServeHTTP(w, r.WithContext(ctx))
+					return
+				}
+			}
+
+			slog.InfoContext(r.Context(), "rejected unauthenticated request", slog.String("path", r.URL.Path), logging.Err(err))
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"error":   "Unauthorized",
+			})
+		})
+	}
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer <token>" header
+func bearerToken(r *http.Request) (string, error) {
+	header := r.Header.Get("Authorization")
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", ErrMissingToken
+	}
+	return strings.TrimSpace(token), nil
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SignatureHeader carries one or more comma-separated "v1=<hex HMAC-SHA256>" signatures
+	SignatureHeader = "X-Signature"
+	// SignatureTimestampHeader carries the Unix time in seconds at which the request was signed
+	SignatureTimestampHeader = "X-Signature-Timestamp"
+
+	signatureVersion = "v1"
+)
+
+var (
+	// ErrMissingSignature is returned when a webhook carries no signature or timestamp
+	ErrMissingSignature = errors.New("missing webhook signature")
+	// ErrInvalidSignature is returned when no signature matches any configured secret
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrStaleSignature is returned when the signed timestamp is outside the tolerance window
+	ErrStaleSignature = errors.New("webhook timestamp outside tolerance")
+)
+
+// SignWebhook returns the signature header value for body signed with secret at timestamp
+// The signed payload is "<unix seconds>.<body>"
+func SignWebhook(secret string, timestamp time.Time, body []byte) string {
+	return signatureVersion + "=" + hex.EncodeToString(webhookMAC([]byte(secret), strconv.FormatInt(timestamp.Unix(), 10), body))
+}
+
+// WebhookVerifier checks HMAC-SHA256 signatures on webhook requests
+// Any configured secret is accepted, so secrets can be rotated by adding the new one before removing the old
+// It keeps no state: the timestamp window bounds how long a captured request stays valid, and the receiver
+// must make a repeated delivery harmless, as the call store does by accepting one result per call
+type WebhookVerifier struct {
+	secrets   [][]byte
+	tolerance time.Duration
+	now       func() time.Time
+}
+
+// NewWebhookVerifier creates a verifier accepting signatures made with any of secrets
+// whose timestamp is within tolerance of the current time
+func NewWebhookVerifier(secrets []string, tolerance time.Duration) *WebhookVerifier {
+	keys := make([][]byte, 0, len(secrets))
+	for _, secret := range secrets {
+		keys = append(keys, []byte(secret))
+	}
+	return &WebhookVerifier{
+		secrets:   keys,
+		tolerance: tolerance,
+		now:       time.Now,
+	}
+}
+
+// Verify checks the signature headers against body
+func (v *WebhookVerifier) Verify(header http.Header, body []byte) error {
+	rawTimestamp := header.Get(SignatureTimestampHeader)
+	signatures := parseSignatures(header.Get(SignatureHeader))
+	if rawTimestamp == "" || len(signatures) == 0 {
+		return ErrMissingSignature
+	}
+
+	unix, err := strconv.ParseInt(rawTimestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	signedAt := time.Unix(unix, 0)
+	now := v.now()
+	if signedAt.Before(now.Add(-v.tolerance)) || signedAt.After(now.Add(v.tolerance)) {
+		return ErrStaleSignature
+	}
+
+	for _, secret := range v.secrets {
+		expected := webhookMAC(secret, rawTimestamp, body)
+		for _, signature := range signatures {
+			if hmac.Equal(expected, signature) {
+				return nil
+			}
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// webhookMAC computes HMAC-SHA256 over "<timestamp>.<body>"
+func webhookMAC(secret []byte, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// parseSignatures decodes the v1 signatures in a signature header, skipping malformed entries
+func parseSignatures(header string) [][]byte {
+	var signatures [][]byte
+	for _, part := range strings.Split(header, ",") {
+		version, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found || version != signatureVersion {
+			continue
+		}
+		if signature, err := hex.DecodeString(value); err == nil && len(signature) == sha256.Size {
+			signatures = append(signatures, signature)
+		}
+	}
+	return signatures
+}
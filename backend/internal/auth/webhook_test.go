@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// signedHeader returns the headers of a body signed with secret at signedAt
+func signedHeader(secret string, signedAt time.Time, body []byte) http.Header {
+	header := http.Header{}
+	header.Set(SignatureTimestampHeader, strconv.FormatInt(signedAt.Unix(), 10))
+	header.Set(SignatureHeader, SignWebhook(secret, signedAt, body))
+	return header
+}
+
+func TestWebhookVerifier(t *testing.T) {
+	now := time.Unix(1_760_000_000, 0)
+	verifier := NewWebhookVerifier([]string{"old-secret", "new-secret"}, 5*time.Minute)
+	verifier.now = func() time.Time { return now }
+	body := []byte(`{"user_id":"call-1","is_available":true}`)
+
+	tampered := signedHeader("new-secret", now, body)
+	tampered.Set(SignatureTimestampHeader, strconv.FormatInt(now.Unix()+1, 10))
+
+	tests := []struct {
+		name   string
+		header http.Header
+		body   []byte
+		want   error
+	}{
+		{"current secret", signedHeader("new-secret", now, body), body, nil},
+		{"previous secret", signedHeader("old-secret", now, body), body, nil},
+		{"unknown secret", signedHeader("other-secret", now, body), body, ErrInvalidSignature},
+		{"modified body", signedHeader("new-secret", now, body), []byte(`{"user_id":"call-2"}`), ErrInvalidSignature},
+		{"modified timestamp", tampered, body, ErrInvalidSignature},
+		{"too old", signedHeader("new-secret", now.Add(-6*time.Minute), body), body, ErrStaleSignature},
+		{"too new", signedHeader("new-secret", now.Add(6*time.Minute), body), body, ErrStaleSignature},
+		{"unsigned", http.Header{}, body, ErrMissingSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := verifier.Verify(tt.header, tt.body); !errors.Is(err, tt.want) {
+				t.Errorf("Verify error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookVerifierAcceptsRetries(t *testing.T) {
+	verifier := NewWebhookVerifier([]string{"secret"}, 5*time.Minute)
+	body := []byte(`{"user_id":"call-1"}`)
+	header := signedHeader("secret", time.Now(), body)
+
+	// A delivery whose processing failed is retried unchanged; the call store rejects true duplicates
+	for attempt := 1; attempt <= 2; attempt++ {
+		if err := verifier.Verify(header, body); err != nil {
+			t.Fatalf("attempt %d: Verify error = %v", attempt, err)
+		}
+	}
+}
@@ -0,0 +1,487 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds every runtime setting of the server
+// Values come from defaults, then an optional YAML or TOML file, then environment variables
+type Config struct {
+	Server    ServerConfig    `yaml:"server" toml:"server"`
+	Database  DatabaseConfig  `yaml:"database" toml:"database"`
+	Agent     AgentConfig     `yaml:"agent" toml:"agent"`
+	Carfax    CarfaxConfig    `yaml:"carfax" toml:"carfax"`
+	Listings  ListingsConfig  `yaml:"listings" toml:"listings"`
+	Log       LogConfig       `yaml:"log" toml:"log"`
+	Tracing   TracingConfig   `yaml:"tracing" toml:"tracing"`
+	Auth      AuthConfig      `yaml:"auth" toml:"auth"`
+	RateLimit RateLimitConfig `yaml:"rate_limit" toml:"rate_limit"`
+	Calls     CallsConfig     `yaml:"calls" toml:"calls"`
+}
+
+// ServerConfig configures the HTTP server
+type ServerConfig struct {
+	Port           string   `yaml:"port" toml:"port"`
+	RequestTimeout Duration `yaml:"request_timeout" toml:"request_timeout"`
+	// ReadHeaderTimeout, ReadTimeout, WriteTimeout and IdleTimeout are applied to the http.Server
+	ReadHeaderTimeout Duration `yaml:"read_header_timeout" toml:"read_header_timeout"`
+	ReadTimeout       Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout      Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout       Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	// ShutdownTimeout bounds how long shutdown waits for in-flight requests and background work
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	// ReadyCheckTimeout bounds each dependency probe behind /ready
+	ReadyCheckTimeout Duration `yaml:"ready_check_timeout" toml:"ready_check_timeout"`
+	// ReadyCacheTTL is how long a /ready report is reused before dependencies are probed again
+	ReadyCacheTTL Duration `yaml:"ready_cache_ttl" toml:"ready_cache_ttl"`
+}
+
+// DatabaseConfig configures the Postgres connection pool
+type DatabaseConfig struct {
+	URL               string   `yaml:"url" toml:"url"`
+	AutoMigrate       bool     `yaml:"auto_migrate" toml:"auto_migrate"`
+	MaxConns          int32    `yaml:"max_conns" toml:"max_conns"`
+	MinConns          int32    `yaml:"min_conns" toml:"min_conns"`
+	MaxConnLifetime   Duration `yaml:"max_conn_lifetime" toml:"max_conn_lifetime"`
+	MaxConnIdleTime   Duration `yaml:"max_conn_idle_time" toml:"max_conn_idle_time"`
+	HealthCheckPeriod Duration `yaml:"health_check_period" toml:"health_check_period"`
+	QueryTimeout      Duration `yaml:"query_timeout" toml:"query_timeout"`
+	ScanTimeout       Duration `yaml:"scan_timeout" toml:"scan_timeout"`
+}
+
+// AgentConfig configures the voice agent service that places calls
+type AgentConfig struct {
+	// URL is the agent service base URL; calls are dispatched to URL + /calls/init
+	URL     string   `yaml:"url" toml:"url"`
+	Timeout Duration `yaml:"timeout" toml:"timeout"`
+	// WebhookSecrets sign the agent's /api/calls/finish callbacks; any of them is accepted so secrets can be rotated
+	WebhookSecrets []string `yaml:"webhook_secrets" toml:"webhook_secrets"`
+	// WebhookTolerance is how far a callback's signed timestamp may be from the current time
+	WebhookTolerance Duration `yaml:"webhook_tolerance" toml:"webhook_tolerance"`
+	// WebhookVerificationDisabled accepts unsigned agent callbacks for local development
+	// It is independent of AuthConfig.Disabled, so turning off user auth never turns off callback signing
+	WebhookVerificationDisabled bool `yaml:"webhook_verification_disabled" toml:"webhook_verification_disabled"`
+}
+
+// CarfaxConfig configures the CARFAX listing provider
+type CarfaxConfig struct {
+	BaseURL  string   `yaml:"base_url" toml:"base_url"`
+	Rows     int      `yaml:"rows" toml:"rows"`
+	MaxPages int      `yaml:"max_pages" toml:"max_pages"`
+	Timeout  Duration `yaml:"timeout" toml:"timeout"`
+}
+
+// ListingsConfig configures where listings come from and how they are cached
+type ListingsConfig struct {
+	Source        string   `yaml:"source" toml:"source"`
+	Fixtures      []string `yaml:"fixtures" toml:"fixtures"`
+	CacheTTL      Duration `yaml:"cache_ttl" toml:"cache_ttl"`
+	CacheStaleTTL Duration `yaml:"cache_stale_ttl" toml:"cache_stale_ttl"`
+	CacheSize     int      `yaml:"cache_size" toml:"cache_size"`
+}
+
+// LogConfig configures structured logging
+type LogConfig struct {
+	// Format is "json" or "text"
+	Format string `yaml:"format" toml:"format"`
+	// Level is debug, info, warn or error
+	Level string `yaml:"level" toml:"level"`
+}
+
+// TracingConfig configures OpenTelemetry trace export
+type TracingConfig struct {
+	// Exporter is "none", "stdout" or "otlp"
+	Exporter string `yaml:"exporter" toml:"exporter"`
+	// OTLPEndpoint is the OTLP/HTTP endpoint URL; when empty the standard OTEL_EXPORTER_OTLP_* variables apply
+	OTLPEndpoint string  `yaml:"otlp_endpoint" toml:"otlp_endpoint"`
+	ServiceName  string  `yaml:"service_name" toml:"service_name"`
+	SampleRatio  float64 `yaml:"sample_ratio" toml:"sample_ratio"`
+}
+
+// AuthConfig configures bearer JWT authentication of user-facing endpoints
+type AuthConfig struct {
+	// Disabled turns authentication off for local development; callers are then trusted to send their user_id
+	Disabled bool   `yaml:"disabled" toml:"disabled"`
+	JWKSURL  string `yaml:"jwks_url" toml:"jwks_url"`
+	Issuer   string `yaml:"issuer" toml:"issuer"`
+	Audience string `yaml:"audience" toml:"audience"`
+	// JWKSRefreshInterval is how often signing keys are re-fetched
+	JWKSRefreshInterval Duration `yaml:"jwks_refresh_interval" toml:"jwks_refresh_interval"`
+	// JWKSUnknownKIDInterval is the minimum gap between refreshes triggered by an unknown key ID
+	JWKSUnknownKIDInterval Duration `yaml:"jwks_unknown_kid_interval" toml:"jwks_unknown_kid_interval"`
+	// TierClaim names the namespaced custom claim carrying the caller's rate limit tier, e.g. "https://api.example.com/tier"
+	// Without it every user gets the default tier
+	TierClaim string `yaml:"tier_claim" toml:"tier_claim"`
+}
+
+// minWebhookSecretLength is the shortest accepted agent webhook secret
+const minWebhookSecretLength = 32
+
+// CallsConfig configures call submission
+type CallsConfig struct {
+	// IdempotencyTTL is how long a submission is replayed for retries with the same Idempotency-Key
+	IdempotencyTTL Duration `yaml:"idempotency_ttl" toml:"idempotency_ttl"`
+	// IdempotencySweepInterval is how often expired Idempotency-Key records are deleted
+	IdempotencySweepInterval Duration    `yaml:"idempotency_sweep_interval" toml:"idempotency_sweep_interval"`
+	Queue                    QueueConfig `yaml:"queue" toml:"queue"`
+}
+
+// QueueConfig configures the workers that dispatch queued calls to the agent service
+type QueueConfig struct {
+	// Workers bounds how many batches are sent to the agent service at once
+	Workers int `yaml:"workers" toml:"workers"`
+	// PollInterval is how often idle workers check for due jobs
+	PollInterval Duration `yaml:"poll_interval" toml:"poll_interval"`
+	// Lease is how long a worker holds a job before another may claim it; it must outlast the agent timeout
+	Lease       Duration `yaml:"lease" toml:"lease"`
+	MaxAttempts int      `yaml:"max_attempts" toml:"max_attempts"`
+	// RetryBaseDelay is the delay after the first failed attempt; it doubles with each attempt up to RetryMaxDelay
+	RetryBaseDelay Duration `yaml:"retry_base_delay" toml:"retry_base_delay"`
+	RetryMaxDelay  Duration `yaml:"retry_max_delay" toml:"retry_max_delay"`
+}
+
+// RateLimitConfig bounds how many calls users can place through /api/calls/submit
+type RateLimitConfig struct {
+	// GlobalPerMinute and GlobalBurst bound the calls placed across all users
+	GlobalPerMinute float64 `yaml:"global_per_minute" toml:"global_per_minute"`
+	GlobalBurst     int     `yaml:"global_burst" toml:"global_burst"`
+	// DefaultTier applies to users whose token grants no configured tier
+	DefaultTier string `yaml:"default_tier" toml:"default_tier"`
+	// Tiers are selected by the caller token's tier claim (AuthConfig.TierClaim)
+	Tiers map[string]TierLimits `yaml:"tiers" toml:"tiers"`
+}
+
+// TierLimits are the per-user limits of one tier
+type TierLimits struct {
+	PerMinute float64 `yaml:"per_minute" toml:"per_minute"`
+	Burst     int     `yaml:"burst" toml:"burst"`
+	// Daily is how many calls a user may place per UTC day
+	Daily int `yaml:"daily" toml:"daily"`
+	// MaxBatch is the most calls a single submit may contain
+	MaxBatch int `yaml:"max_batch" toml:"max_batch"`
+}
+
+// Default returns the configuration used when nothing overrides it
+// DATABASE_URL and AGENT_URL have no defaults and must always be provided
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:              "8080",
+			RequestTimeout:    Duration(45 * time.Second),
+			ReadHeaderTimeout: Duration(5 * time.Second),
+			ReadTimeout:       Duration(15 * time.Second),
+			WriteTimeout:      Duration(60 * time.Second),
+			IdleTimeout:       Duration(2 * time.Minute),
+			ShutdownTimeout:   Duration(30 * time.Second),
+			ReadyCheckTimeout: Duration(2 * time.Second),
+			ReadyCacheTTL:     Duration(5 * time.Second),
+		},
+		Database: DatabaseConfig{
+			MaxConns:          25,
+			MinConns:          5,
+			MaxConnLifetime:   Duration(5 * time.Minute),
+			MaxConnIdleTime:   Duration(30 * time.Second),
+			HealthCheckPeriod: Duration(time.Minute),
+			QueryTimeout:      Duration(5 * time.Second),
+			ScanTimeout:       Duration(10 * time.Second),
+		},
+		Agent: AgentConfig{
+			Timeout:          Duration(30 * time.Second),
+			WebhookTolerance: Duration(5 * time.Minute),
+		},
+		Carfax: CarfaxConfig{
+			BaseURL:  "https://helix.carfax.com/search/v2/vehicles",
+			Rows:     24,
+			MaxPages: 5,
+			Timeout:  Duration(20 * time.Second),
+		},
+		Listings: ListingsConfig{
+			Source:        "carfax",
+			CacheTTL:      Duration(time.Minute),
+			CacheStaleTTL: Duration(5 * time.Minute),
+			CacheSize:     256,
+		},
+		Log: LogConfig{
+			Format: "json",
+			Level:  "info",
+		},
+		Auth: AuthConfig{
+			JWKSRefreshInterval:    Duration(time.Hour),
+			JWKSUnknownKIDInterval: Duration(5 * time.Minute),
+		},
+		RateLimit: RateLimitConfig{
+			GlobalPerMinute: 60,
+			GlobalBurst:     30,
+			DefaultTier:     "standard",
+			Tiers: map[string]TierLimits{
+				"standard": {PerMinute: 10, Burst: 10, Daily: 50, MaxBatch: 10},
+			},
+		},
+		Calls: CallsConfig{
+			IdempotencyTTL:           Duration(24 * time.Hour),
+			IdempotencySweepInterval: Duration(15 * time.Minute),
+			Queue: QueueConfig{
+				Workers:        4,
+				PollInterval:   Duration(time.Second),
+				Lease:          Duration(2 * time.Minute),
+				MaxAttempts:    5,
+				RetryBaseDelay: Duration(10 * time.Second),
+				RetryMaxDelay:  Duration(5 * time.Minute),
+			},
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			ServiceName: "carseller-backend",
+			SampleRatio: 1,
+		},
+	}
+}
+
+// Load builds the configuration from defaults, the YAML or TOML file at path (if not empty) and the environment,
+// then validates it
+func Load(path string) (Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return cfg, fmt.Errorf("failed to read config file: %w", err)
+		}
+
+		if err := decode(path, data, &cfg); err != nil {
+			return cfg, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	}
+
+	if err := cfg.applyEnv(os.LookupEnv); err != nil {
+		return cfg, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+// decode reads a TOML file when path ends in .toml and YAML otherwise, rejecting settings Config does not have
+func decode(path string, data []byte, cfg *Config) error {
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		meta, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return err
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			keys := make([]string, len(undecoded))
+			for i, key := range undecoded {
+				keys[i] = key.String()
+			}
+			return fmt.Errorf("unknown settings: %s", strings.Join(keys, ", "))
+		}
+		return nil
+	}
+
+	decoder := yaml.NewDecoder(strings.NewReader(string(data)))
+	decoder.KnownFields(true)
+	return decoder.Decode(cfg)
+}
+
+// Validate checks that required values are present and every value is in range
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Port != "", "server port is required (PORT)")
+	check(c.Server.RequestTimeout > 0, "request timeout must be positive (REQUEST_TIMEOUT)")
+	check(c.Server.ReadHeaderTimeout > 0, "read header timeout must be positive (HTTP_READ_HEADER_TIMEOUT)")
+	check(c.Server.ReadTimeout > 0, "read timeout must be positive (HTTP_READ_TIMEOUT)")
+	check(c.Server.WriteTimeout > c.Server.RequestTimeout,
+		"write timeout must be longer than the request timeout so responses can be written (HTTP_WRITE_TIMEOUT)")
+	check(c.Server.IdleTimeout > 0, "idle timeout must be positive (HTTP_IDLE_TIMEOUT)")
+	check(c.Server.ShutdownTimeout > 0, "shutdown timeout must be positive (SHUTDOWN_TIMEOUT)")
+	check(c.Server.ReadyCheckTimeout > 0, "readiness check timeout must be positive (READY_CHECK_TIMEOUT)")
+	check(c.Server.ReadyCacheTTL >= 0, "readiness cache TTL must not be negative (READY_CACHE_TTL)")
+
+	check(c.Database.URL != "", "database URL is required (DATABASE_URL)")
+	check(c.Database.MaxConns > 0, "database max_conns must be positive (DB_MAX_CONNS)")
+	check(c.Database.MinConns >= 0 && c.Database.MinConns <= c.Database.MaxConns,
+		"database min_conns must be between 0 and max_conns (DB_MIN_CONNS)")
+	check(c.Database.QueryTimeout > 0, "database query timeout must be positive (DB_QUERY_TIMEOUT)")
+	check(c.Database.ScanTimeout > 0, "database scan timeout must be positive (DB_SCAN_TIMEOUT)")
+
+	check(c.Agent.URL != "", "agent service URL is required (AGENT_URL)")
+	check(c.Agent.URL == "" || isHTTPURL(c.Agent.URL), "agent service URL must be an http(s) URL (AGENT_URL)")
+	check(c.Agent.Timeout > 0, "agent timeout must be positive (AGENT_TIMEOUT)")
+	check(len(c.Agent.WebhookSecrets) > 0 || c.Agent.WebhookVerificationDisabled,
+		"agent webhook secrets are required unless AGENT_WEBHOOK_VERIFICATION_DISABLED=true (AGENT_WEBHOOK_SECRETS)")
+	for i, secret := range c.Agent.WebhookSecrets {
+		check(len(secret) >= minWebhookSecretLength,
+			"agent webhook secret %d must be at least %d characters (AGENT_WEBHOOK_SECRETS)", i+1, minWebhookSecretLength)
+	}
+	check(c.Agent.WebhookTolerance > 0, "agent webhook tolerance must be positive (AGENT_WEBHOOK_TOLERANCE)")
+
+	check(isHTTPURL(c.Carfax.BaseURL), "CARFAX base URL must be an http(s) URL (CARFAX_BASE_URL)")
+	check(c.Carfax.Rows > 0, "CARFAX rows must be positive (CARFAX_ROWS)")
+	check(c.Carfax.MaxPages > 0, "CARFAX max pages must be positive (CARFAX_MAX_PAGES)")
+	check(c.Carfax.Timeout > 0, "CARFAX timeout must be positive (CARFAX_TIMEOUT)")
+
+	switch c.Listings.Source {
+	case "carfax":
+	case "fixture", "all":
+		check(len(c.Listings.Fixtures) > 0, "listings source %q needs fixture paths (LISTINGS_FIXTURES)", c.Listings.Source)
+	default:
+		check(false, "listings source must be carfax, fixture or all (LISTINGS_SOURCE), got %q", c.Listings.Source)
+	}
+	check(c.Listings.CacheTTL >= 0, "listing cache TTL must not be negative (LISTINGS_CACHE_TTL)")
+	check(c.Listings.CacheStaleTTL >= 0, "listing cache stale TTL must not be negative (LISTINGS_CACHE_STALE_TTL)")
+	check(c.Listings.CacheSize > 0, "listing cache size must be positive (LISTINGS_CACHE_SIZE)")
+
+	check(c.Log.Format == "json" || c.Log.Format == "text", "log format must be json or text (LOG_FORMAT), got %q", c.Log.Format)
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Log.Level)) == nil, "log level must be debug, info, warn or error (LOG_LEVEL), got %q", c.Log.Level)
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		check(false, "trace exporter must be none, stdout or otlp (TRACING_EXPORTER), got %q", c.Tracing.Exporter)
+	}
+	check(c.Tracing.OTLPEndpoint == "" || isHTTPURL(c.Tracing.OTLPEndpoint), "OTLP endpoint must be an http(s) URL (TRACING_OTLP_ENDPOINT)")
+	check(c.Tracing.ServiceName != "", "tracing service name is required (OTEL_SERVICE_NAME)")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "trace sample ratio must be between 0 and 1 (TRACING_SAMPLE_RATIO)")
+
+	if !c.Auth.Disabled {
+		check(isHTTPURL(c.Auth.JWKSURL), "JWKS URL is required unless AUTH_DISABLED=true (AUTH_JWKS_URL)")
+		check(c.Auth.Issuer != "", "token issuer is required unless AUTH_DISABLED=true (AUTH_ISSUER)")
+		check(c.Auth.Audience != "", "token audience is required unless AUTH_DISABLED=true (AUTH_AUDIENCE)")
+		check(c.Auth.JWKSRefreshInterval > 0, "JWKS refresh interval must be positive (AUTH_JWKS_REFRESH_INTERVAL)")
+		check(c.Auth.JWKSUnknownKIDInterval > 0, "JWKS unknown key refresh interval must be positive (AUTH_JWKS_UNKNOWN_KID_INTERVAL)")
+		// Namespaced claims are set by the identity provider, unlike scopes that clients may request themselves
+		check(c.Auth.TierClaim == "" || isHTTPURL(c.Auth.TierClaim), "token tier claim must be a namespaced URL such as https://api.example.com/tier (AUTH_TIER_CLAIM)")
+	}
+
+	check(c.RateLimit.GlobalPerMinute > 0, "global rate limit must be positive (RATE_LIMIT_GLOBAL_PER_MINUTE)")
+	check(c.RateLimit.GlobalBurst > 0, "global burst must be positive (RATE_LIMIT_GLOBAL_BURST)")
+	_, hasDefaultTier := c.RateLimit.Tiers[c.RateLimit.DefaultTier]
+	check(hasDefaultTier, "default rate limit tier %q is not configured (RATE_LIMIT_DEFAULT_TIER)", c.RateLimit.DefaultTier)
+	tierNames := make([]string, 0, len(c.RateLimit.Tiers))
+	for name := range c.RateLimit.Tiers {
+		tierNames = append(tierNames, name)
+	}
+	slices.Sort(tierNames)
+	for _, name := range tierNames {
+		tier := c.RateLimit.Tiers[name]
+		check(tier.PerMinute > 0, "rate limit tier %q: per_minute must be positive", name)
+		check(tier.Burst > 0, "rate limit tier %q: burst must be positive", name)
+		check(tier.Daily > 0, "rate limit tier %q: daily must be positive", name)
+		// A batch larger than a bucket could never be admitted
+		check(tier.MaxBatch > 0 && tier.MaxBatch <= tier.Burst && tier.MaxBatch <= c.RateLimit.GlobalBurst && tier.MaxBatch <= tier.Daily,
+			"rate limit tier %q: max_batch must be positive and no larger than burst, daily and the global burst", name)
+	}
+
+	check(c.Calls.IdempotencyTTL >= c.Server.RequestTimeout,
+		"idempotency TTL must be at least the request timeout (CALLS_IDEMPOTENCY_TTL)")
+	check(c.Calls.IdempotencySweepInterval > 0, "idempotency sweep interval must be positive (CALLS_IDEMPOTENCY_SWEEP_INTERVAL)")
+	check(c.Calls.Queue.Workers > 0, "queue workers must be positive (CALLS_QUEUE_WORKERS)")
+	check(c.Calls.Queue.PollInterval > 0, "queue poll interval must be positive (CALLS_QUEUE_POLL_INTERVAL)")
+	// A lease that ran out mid-request would let a second worker dial the same calls
+	check(c.Calls.Queue.Lease > c.Agent.Timeout, "queue lease must be longer than the agent timeout (CALLS_QUEUE_LEASE)")
+	check(c.Calls.Queue.MaxAttempts > 0, "queue max attempts must be positive (CALLS_QUEUE_MAX_ATTEMPTS)")
+	check(c.Calls.Queue.RetryBaseDelay > 0, "queue retry base delay must be positive (CALLS_QUEUE_RETRY_BASE_DELAY)")
+	check(c.Calls.Queue.RetryMaxDelay >= c.Calls.Queue.RetryBaseDelay,
+		"queue retry max delay must be at least the base delay (CALLS_QUEUE_RETRY_MAX_DELAY)")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// String renders the configuration as it is logged, with secrets redacted
+// It is built from LogValue so the two cannot drift apart, and keeps %v from printing secrets
+func (c Config) String() string {
+	attrs := c.LogValue().Group()
+	parts := make([]string, len(attrs))
+	for i, attr := range attrs {
+		parts[i] = attr.String()
+	}
+	return strings.Join(parts, " ")
+}
+
+// LogValue implements slog.LogValuer with secrets redacted
+func (c Config) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("port", c.Server.Port),
+		slog.Duration("request_timeout", c.Server.RequestTimeout.Std()),
+		slog.String("database", RedactURL(c.Database.URL)),
+		slog.Bool("auto_migrate", c.Database.AutoMigrate),
+		slog.Int("max_conns", int(c.Database.MaxConns)),
+		slog.String("agent", RedactURL(c.Agent.URL)),
+		slog.Int("agent_webhook_secrets", len(c.Agent.WebhookSecrets)),
+		slog.Bool("agent_webhook_verification_disabled", c.Agent.WebhookVerificationDisabled),
+		slog.String("carfax", RedactURL(c.Carfax.BaseURL)),
+		slog.Int("carfax_max_pages", c.Carfax.MaxPages),
+		slog.Int("queue_workers", c.Calls.Queue.Workers),
+		slog.String("listings", c.Listings.Source),
+		slog.Duration("cache_ttl", c.Listings.CacheTTL.Std()),
+		slog.String("log_level", c.Log.Level),
+		slog.String("trace_exporter", c.Tracing.Exporter),
+		slog.Bool("auth_disabled", c.Auth.Disabled),
+		slog.String("auth_issuer", c.Auth.Issuer),
+		slog.String("auth_tier_claim", c.Auth.TierClaim),
+	)
+}
+
+// dsnPassword matches the password settings of a key=value connection string, quoted or not
+var dsnPassword = regexp.MustCompile(`(?i)\b(\w*password\s*=\s*)('(?:[^'\\]|\\.)*'|\S*)`)
+
+// RedactURL masks the password and any query string in a URL so it can be logged
+// Postgres key=value connection strings have their password settings masked instead
+func RedactURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return "[redacted]"
+	}
+
+	// Without a scheme this is not a URL; url.Parse would hand it back unchanged
+	if parsed.Scheme == "" {
+		if !strings.Contains(raw, "=") {
+			return "[redacted]"
+		}
+		return dsnPassword.ReplaceAllString(raw, "${1}xxxxx")
+	}
+
+	if _, hasPassword := parsed.User.Password(); hasPassword {
+		parsed.User = url.UserPassword(parsed.User.Username(), "xxxxx")
+	}
+	if parsed.RawQuery != "" {
+		parsed.RawQuery = "redacted"
+	}
+
+	return parsed.String()
+}
+
+// isHTTPURL reports whether raw is an absolute http or https URL
+func isHTTPURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedactURL(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"", ""},
+		{"postgres://app:secret@db:5432/cars", "postgres://app:xxxxx@db:5432/cars"},
+		{"postgres://db/cars?password=secret", "postgres://db/cars?redacted"},
+		{"https://helix.carfax.com/search/v2/vehicles", "https://helix.carfax.com/search/v2/vehicles"},
+		{"host=db user=u password=secret dbname=x", "host=db user=u password=xxxxx dbname=x"},
+		{"host=db password = 'two words' dbname=x", "host=db password = xxxxx dbname=x"},
+		{`host=db password='se\'cret' sslpassword=key`, "host=db password=xxxxx sslpassword=xxxxx"},
+		{"not a url", "[redacted]"},
+	}
+	for _, tt := range tests {
+		if got := RedactURL(tt.raw); got != tt.want {
+			t.Errorf("RedactURL(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestValidateWebhookSecrets(t *testing.T) {
+	secret := strings.Repeat("s", minWebhookSecretLength)
+	tests := []struct {
+		name                string
+		authDisabled        bool
+		verificationOff     bool
+		secrets             []string
+		wantSecretsRequired bool
+	}{
+		{"secrets set", false, false, []string{secret}, false},
+		{"missing", false, false, nil, true},
+		{"missing with auth disabled", true, false, nil, true},
+		{"verification disabled", false, true, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Default()
+			c.Database.URL = "postgres://db/cars"
+			c.Agent.URL = "https://agent.example.com"
+			c.Auth = AuthConfig{Disabled: tt.authDisabled, JWKSURL: "https://issuer.example.com/jwks.json", Issuer: "issuer", Audience: "api",
+				JWKSRefreshInterval: c.Auth.JWKSRefreshInterval, JWKSUnknownKIDInterval: c.Auth.JWKSUnknownKIDInterval}
+			c.Agent.WebhookSecrets = tt.secrets
+			c.Agent.WebhookVerificationDisabled = tt.verificationOff
+
+			err := c.Validate()
+			required := err != nil && strings.Contains(err.Error(), "AGENT_WEBHOOK_SECRETS")
+			if required != tt.wantSecretsRequired {
+				t.Errorf("Validate() = %v, want secrets required %v", err, tt.wantSecretsRequired)
+			}
+		})
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeFile(t, path, `
+[server]
+port = "9090"
+request_timeout = "20s"
+
+[database]
+url = "postgres://app:secret@db:5432/cars"
+
+[agent]
+url = "https://agent.example.com"
+webhook_secrets = ["`+strings.Repeat("s", minWebhookSecretLength)+`"]
+
+[auth]
+disabled = true
+
+[rate_limit.tiers.standard]
+per_minute = 5
+burst = 5
+daily = 20
+max_batch = 5
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Server.Port != "9090" || cfg.Server.RequestTimeout.Std() != 20*time.Second {
+		t.Errorf("server = %+v, want port 9090 and a 20s request timeout", cfg.Server)
+	}
+	if tier := cfg.RateLimit.Tiers["standard"]; tier.PerMinute != 5 || tier.Daily != 20 {
+		t.Errorf("standard tier = %+v, want the file's limits", tier)
+	}
+	// Unset settings keep their defaults
+	if cfg.Database.MaxConns != Default().Database.MaxConns {
+		t.Errorf("max conns = %d, want the default %d", cfg.Database.MaxConns, Default().Database.MaxConns)
+	}
+
+	writeFile(t, path, "[server]\nprot = \"9090\"\n")
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "server.prot") {
+		t.Errorf("Load with an unknown setting = %v, want it named", err)
+	}
+	writeFile(t, path, "[server]\nrequest_timeout = \"soon\"\n")
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "not a duration") {
+		t.Errorf("Load with a bad duration = %v, want a duration error", err)
+	}
+}
+
+func TestStringMatchesLogValue(t *testing.T) {
+	c := Default()
+	c.Database.URL = "postgres://app:hunter2@db:5432/cars"
+
+	got := c.String()
+	if strings.Contains(got, "hunter2") {
+		t.Errorf("String() = %q, leaks the database password", got)
+	}
+	for _, attr := range c.LogValue().Group() {
+		if !strings.Contains(got, attr.String()) {
+			t.Errorf("String() = %q, missing %s", got, attr)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
@@ -0,0 +1,201 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lookupFunc matches os.LookupEnv
+type lookupFunc func(string) (string, bool)
+
+// applyEnv overrides the configuration with any environment variables that are set
+func (c *Config) applyEnv(lookup lookupFunc) error {
+	env := envReader{lookup: lookup}
+
+	env.string("PORT", &c.Server.Port)
+	env.duration("REQUEST_TIMEOUT", &c.Server.RequestTimeout)
+	env.duration("HTTP_READ_HEADER_TIMEOUT", &c.Server.ReadHeaderTimeout)
+	env.duration("HTTP_READ_TIMEOUT", &c.Server.ReadTimeout)
+	env.duration("HTTP_WRITE_TIMEOUT", &c.Server.WriteTimeout)
+	env.duration("HTTP_IDLE_TIMEOUT", &c.Server.IdleTimeout)
+	env.duration("SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+	env.duration("READY_CHECK_TIMEOUT", &c.Server.ReadyCheckTimeout)
+	env.duration("READY_CACHE_TTL", &c.Server.ReadyCacheTTL)
+
+	env.string("DATABASE_URL", &c.Database.URL)
+	env.bool("DB_AUTO_MIGRATE", &c.Database.AutoMigrate)
+	env.int32("DB_MAX_CONNS", &c.Database.MaxConns)
+	env.int32("DB_MIN_CONNS", &c.Database.MinConns)
+	env.duration("DB_MAX_CONN_LIFETIME", &c.Database.MaxConnLifetime)
+	env.duration("DB_MAX_CONN_IDLE_TIME", &c.Database.MaxConnIdleTime)
+	env.duration("DB_HEALTH_CHECK_PERIOD", &c.Database.HealthCheckPeriod)
+	env.duration("DB_QUERY_TIMEOUT", &c.Database.QueryTimeout)
+	env.duration("DB_SCAN_TIMEOUT", &c.Database.ScanTimeout)
+
+	env.string("AGENT_URL", &c.Agent.URL)
+	env.duration("AGENT_TIMEOUT", &c.Agent.Timeout)
+	env.list("AGENT_WEBHOOK_SECRETS", &c.Agent.WebhookSecrets)
+	env.duration("AGENT_WEBHOOK_TOLERANCE", &c.Agent.WebhookTolerance)
+	env.bool("AGENT_WEBHOOK_VERIFICATION_DISABLED", &c.Agent.WebhookVerificationDisabled)
+
+	env.string("CARFAX_BASE_URL", &c.Carfax.BaseURL)
+	env.int("CARFAX_ROWS", &c.Carfax.Rows)
+	env.int("CARFAX_MAX_PAGES", &c.Carfax.MaxPages)
+	env.duration("CARFAX_TIMEOUT", &c.Carfax.Timeout)
+
+	env.string("LISTINGS_SOURCE", &c.Listings.Source)
+	env.list("LISTINGS_FIXTURES", &c.Listings.Fixtures)
+	env.duration("LISTINGS_CACHE_TTL", &c.Listings.CacheTTL)
+	env.duration("LISTINGS_CACHE_STALE_TTL", &c.Listings.CacheStaleTTL)
+	env.int("LISTINGS_CACHE_SIZE", &c.Listings.CacheSize)
+
+	env.string("LOG_FORMAT", &c.Log.Format)
+	env.string("LOG_LEVEL", &c.Log.Level)
+
+	env.bool("AUTH_DISABLED", &c.Auth.Disabled)
+	env.string("AUTH_JWKS_URL", &c.Auth.JWKSURL)
+	env.string("AUTH_ISSUER", &c.Auth.Issuer)
+	env.string("AUTH_AUDIENCE", &c.Auth.Audience)
+	env.duration("AUTH_JWKS_REFRESH_INTERVAL", &c.Auth.JWKSRefreshInterval)
+	env.duration("AUTH_JWKS_UNKNOWN_KID_INTERVAL", &c.Auth.JWKSUnknownKIDInterval)
+	env.string("AUTH_TIER_CLAIM", &c.Auth.TierClaim)
+
+	env.float64("RATE_LIMIT_GLOBAL_PER_MINUTE", &c.RateLimit.GlobalPerMinute)
+	env.int("RATE_LIMIT_GLOBAL_BURST", &c.RateLimit.GlobalBurst)
+	env.string("RATE_LIMIT_DEFAULT_TIER", &c.RateLimit.DefaultTier)
+
+	env.duration("CALLS_IDEMPOTENCY_TTL", &c.Calls.IdempotencyTTL)
+	env.duration("CALLS_IDEMPOTENCY_SWEEP_INTERVAL", &c.Calls.IdempotencySweepInterval)
+	env.int("CALLS_QUEUE_WORKERS", &c.Calls.Queue.Workers)
+	env.duration("CALLS_QUEUE_POLL_INTERVAL", &c.Calls.Queue.PollInterval)
+	env.duration("CALLS_QUEUE_LEASE", &c.Calls.Queue.Lease)
+	env.int("CALLS_QUEUE_MAX_ATTEMPTS", &c.Calls.Queue.MaxAttempts)
+	env.duration("CALLS_QUEUE_RETRY_BASE_DELAY", &c.Calls.Queue.RetryBaseDelay)
+	env.duration("CALLS_QUEUE_RETRY_MAX_DELAY", &c.Calls.Queue.RetryMaxDelay)
+
+	env.string("TRACING_EXPORTER", &c.Tracing.Exporter)
+	env.string("TRACING_OTLP_ENDPOINT", &c.Tracing.OTLPEndpoint)
+	env.string("OTEL_SERVICE_NAME", &c.Tracing.ServiceName)
+	env.float64("TRACING_SAMPLE_RATIO", &c.Tracing.SampleRatio)
+
+	return env.err()
+}
+
+// envReader parses environment variables into typed fields, collecting every parse error
+type envReader struct {
+	lookup lookupFunc
+	errs   []error
+}
+
+func (e *envReader) get(name string) (string, bool) {
+	value, ok := e.lookup(name)
+	if !ok || strings.TrimSpace(value) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(value), true
+}
+
+func (e *envReader) fail(name, expected string) {
+	e.errs = append(e.errs, fmt.Errorf("%s must be %s", name, expected))
+}
+
+func (e *envReader) string(name string, target *string) {
+	if value, ok := e.get(name); ok {
+		*target = value
+	}
+}
+
+func (e *envReader) list(name string, target *[]string) {
+	value, ok := e.get(name)
+	if !ok {
+		return
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*target = items
+}
+
+func (e *envReader) bool(name string, target *bool) {
+	value, ok := e.get(name)
+	if !ok {
+		return
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		e.fail(name, "true or false")
+		return
+	}
+	*target = parsed
+}
+
+func (e *envReader) int(name string, target *int) {
+	value, ok := e.get(name)
+	if !ok {
+		return
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		e.fail(name, "an integer")
+		return
+	}
+	*target = parsed
+}
+
+func (e *envReader) int32(name string, target *int32) {
+	value, ok := e.get(name)
+	if !ok {
+		return
+	}
+
+	parsed, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		e.fail(name, "an integer")
+		return
+	}
+	*target = int32(parsed)
+}
+
+func (e *envReader) float64(name string, target *float64) {
+	value, ok := e.get(name)
+	if !ok {
+		return
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		e.fail(name, "a number")
+		return
+	}
+	*target = parsed
+}
+
+func (e *envReader) duration(name string, target *Duration) {
+	value, ok := e.get(name)
+	if !ok {
+		return
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		e.fail(name, "a duration such as 30s or 5m")
+		return
+	}
+	*target = Duration(parsed)
+}
+
+func (e *envReader) err() error {
+	if len(e.errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid environment: %w", errors.Join(e.errs...))
+}
@@ -2,96 +2,409 @@ package database
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // Call represents a call record in the database
 type Call struct {
-	ID           int64     `json:"id"`
-	UserID       *string   `json:"user_id,omitempty"`
-	CallID       *string   `json:"call_id,omitempty"`
-	Model        *string   `json:"model,omitempty"`
-	Year         *int      `json:"year,omitempty"`
-	ZipCode      *string   `json:"zipcode,omitempty"`
-	DealerName   *string   `json:"dealer_name,omitempty"`
-	PhoneNumber  *string   `json:"phone_number,omitempty"`
-	MSRP         *int64    `json:"msrp,omitempty"`
-	ListingPrice *int64    `json:"listing_price,omitempty"`
-	Status       *string   `json:"status,omitempty"`
-	IsAvailable  *bool     `json:"is_available,omitempty"`
-	DealPrice    *int64    `json:"deal_price,omitempty"`
-	Remarks      *string   `json:"remarks,omitempty"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           int64       `json:"id"`
+	UserID       *string     `json:"user_id,omitempty"`
+	CallID       *string     `json:"call_id,omitempty"`
+	Make         *string     `json:"make,omitempty"`
+	Model        *string     `json:"model,omitempty"`
+	Year         *int        `json:"year,omitempty"`
+	Condition    *string     `json:"condition,omitempty"`
+	ZipCode      *string     `json:"zipcode,omitempty"`
+	DealerName   *string     `json:"dealer_name,omitempty"`
+	PhoneNumber  *string     `json:"phone_number,omitempty"`
+	MSRP         *int64      `json:"msrp,omitempty"`
+	ListingPrice *int64      `json:"listing_price,omitempty"`
+	Status       *CallStatus `json:"status,omitempty"`
+	IsAvailable  *bool       `json:"is_available,omitempty"`
+	DealPrice    *int64      `json:"deal_price,omitempty"`
+	Remarks      *string     `json:"remarks,omitempty"`
+	TraceParent  *string     `json:"trace_parent,omitempty"`
+	// DispatchError is why the agent service did not accept the call, when Status is dispatch_failed
+	DispatchError *string   `json:"dispatch_error,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// callColumns is the column list scanned by scanCall
+const callColumns = `
+	id, user_id, call_id, make, model, year, vehicle_condition, zipcode, dealer_name, phone_number,
+	msrp, listing_price, status, is_available, deal_price, remarks, trace_parent, dispatch_error,
+	created_at, updated_at
+`
+
+// insertCallQuery inserts one NewCall in the 'pending' status
+const insertCallQuery = `
+	INSERT INTO calls (user_id, call_id, make, model, year, vehicle_condition, zipcode, dealer_name, phone_number, msrp, listing_price, trace_parent, status)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, ''), 'pending')
+`
+
+// insertTransitionQuery records one status change of a call
+const insertTransitionQuery = `
+	INSERT INTO call_status_transitions (call_id, from_status, to_status, reason)
+	VALUES ($1, $2, $3, NULLIF($4, ''))
+`
+
+// uniqueViolation is the Postgres error code for a unique constraint violation
+const uniqueViolation = "23505"
+
+// maxIdempotencyReserveAttempts bounds how often ReserveIdempotencyKey retries when the key is freed under it
+const maxIdempotencyReserveAttempts = 3
+
+// PgCallStore is the Postgres-backed CallStore
+type PgCallStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPgCallStore creates a CallStore over a pgx connection pool
+func NewPgCallStore(pool *pgxpool.Pool) *PgCallStore {
+	return &PgCallStore{pool: pool}
 }
 
 // CreateCall inserts a new call record with backend-generated call_id
-func CreateCall(userID, callID, model string, year int, zipcode, dealerName, phoneNumber string, msrp, listingPrice int64) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *PgCallStore) CreateCall(ctx context.Context, call NewCall) error {
+	return s.CreateCalls(ctx, []NewCall{call})
+}
+
+// CreateCalls inserts call records in a single transaction
+func (s *PgCallStore) CreateCalls(ctx context.Context, calls []NewCall) error {
+	ctx, cancel := queryContext(ctx)
 	defer cancel()
 
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		return insertCallsTx(ctx, tx, calls)
+	})
+	return duplicateCallError(err)
+}
+
+// insertCallsTx inserts calls in the 'pending' status with their creation transitions within an open transaction
+func insertCallsTx(ctx context.Context, tx pgx.Tx, calls []NewCall) error {
+	batch := &pgx.Batch{}
+	for _, call := range calls {
+		batch.Queue(insertCallQuery, call.UserID, call.CallID, call.Make, call.Model, call.Year, call.Condition,
+			call.ZipCode, call.DealerName, call.PhoneNumber, call.MSRP, call.ListingPrice, call.TraceParent)
+		batch.Queue(insertTransitionQuery, call.CallID, nil, StatusPending, "created")
+	}
+	return tx.SendBatch(ctx, batch).Close()
+}
+
+// duplicateCallError maps a unique violation on insert to ErrDuplicateCallID
+func duplicateCallError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrDuplicateCallID
+	}
+	return err
+}
+
+// TransitionCalls moves calls to a new status
+func (s *PgCallStore) TransitionCalls(ctx context.Context, callIDs []string, to CallStatus, reason string) error {
 	query := `
-		INSERT INTO calls (user_id, call_id, model, year, zipcode, dealer_name, phone_number, msrp, listing_price, status)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 'pending')
+		UPDATE calls
+		SET status = $2, updated_at = now()
+		WHERE call_id = ANY($1)
 	`
 
-	_, err := Pool.Exec(ctx, query, userID, callID, model, year, zipcode, dealerName, phoneNumber, msrp, listingPrice)
-	return err
+	return s.transitionCalls(ctx, callIDs, to, reason, query)
 }
 
 // UpdateCallResult updates a call with completion results
-func UpdateCallResult(callID string, isAvailable bool, dealPrice int, remarks string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *PgCallStore) UpdateCallResult(ctx context.Context, callID string, result CallResult) error {
+	if !result.Status.IsResult() {
+		return fmt.Errorf("%w: %s is not a call result", ErrInvalidTransition, result.Status)
+	}
+
+	query := `
+		UPDATE calls
+		SET status = $2, is_available = $3, deal_price = $4, remarks = $5, updated_at = now()
+		WHERE call_id = ANY($1)
+	`
+
+	return s.transitionCalls(ctx, []string{callID}, result.Status, "result reported", query,
+		result.IsAvailable, int64(result.DealPrice), result.Remarks)
+}
+
+// transitionCalls moves calls to status to in one transaction, after checking every move against the transition table
+// update changes the locked rows, with $1 the call IDs, $2 the new status and args as further parameters
+func (s *PgCallStore) transitionCalls(ctx context.Context, callIDs []string, to CallStatus, reason, update string, args ...any) error {
+	ctx, cancel := queryContext(ctx)
 	defer cancel()
 
-	status := "completed"
-	if !isAvailable {
-		status = "failed"
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		return transitionCallsTx(ctx, tx, callIDs, to, reason, update, args...)
+	})
+}
+
+// transitionCallsTx is transitionCalls within an open transaction
+func transitionCallsTx(ctx context.Context, tx pgx.Tx, callIDs []string, to CallStatus, reason, update string, args ...any) error {
+	// Lock the rows so concurrent transitions of the same call are checked one after the other
+	rows, err := tx.Query(ctx, `SELECT call_id, status FROM calls WHERE call_id = ANY($1) FOR UPDATE`, callIDs)
+	if err != nil {
+		return err
+	}
+	current := make(map[string]CallStatus, len(callIDs))
+	var callID string
+	var status CallStatus
+	_, err = pgx.ForEachRow(rows, []any{&callID, &status}, func() error {
+		current[callID] = status
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	// Convert int to int64 for deal_price
-	dealPriceInt := int64(dealPrice)
+	for _, callID := range callIDs {
+		from, ok := current[callID]
+		if !ok {
+			return ErrCallNotFound
+		}
+		if err := checkTransition(callID, from, to); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(ctx, update, append([]any{callIDs, to}, args...)...); err != nil {
+		return err
+	}
+
+	batch := &pgx.Batch{}
+	for _, callID := range callIDs {
+		batch.Queue(insertTransitionQuery, callID, current[callID], to, reason)
+	}
+	return tx.SendBatch(ctx, batch).Close()
+}
+
+// GetCallTransitions returns the recorded status changes of a call
+func (s *PgCallStore) GetCallTransitions(ctx context.Context, callID string) ([]CallTransition, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
 
 	query := `
-		UPDATE calls 
-		SET is_available = $2, deal_price = $3, remarks = $4, status = $5, updated_at = now()
+		SELECT from_status, to_status, reason, created_at
+		FROM call_status_transitions
 		WHERE call_id = $1
+		ORDER BY id
 	`
 
-	result, err := Pool.Exec(ctx, query, callID, isAvailable, dealPriceInt, remarks, status)
+	rows, err := s.pool.Query(ctx, query, callID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	transitions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (CallTransition, error) {
+		var transition CallTransition
+		err := row.Scan(&transition.From, &transition.To, &transition.Reason, &transition.At)
+		return transition, err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if result.RowsAffected() == 0 {
-		return fmt.Errorf("no call found with call_id: %s", callID)
+	// Every call has a creation transition, so none means the call does not exist
+	if len(transitions) == 0 {
+		var exists bool
+		if err := s.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM calls WHERE call_id = $1)`, callID).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrCallNotFound
+		}
+	}
+
+	return transitions, nil
+}
+
+// GetCallTraceParent returns the traceparent stored when the call was dispatched
+func (s *PgCallStore) GetCallTraceParent(ctx context.Context, callID string) (string, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	var traceParent *string
+	err := s.pool.QueryRow(ctx, `SELECT trace_parent FROM calls WHERE call_id = $1`, callID).Scan(&traceParent)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrCallNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return valueOr(traceParent, ""), nil
+}
+
+// ConsumeDailyQuota atomically adds n to the user's daily call count if it stays within limit
+func (s *PgCallStore) ConsumeDailyQuota(ctx context.Context, userID string, day time.Time, n, limit int) (int, bool, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	day = day.UTC()
+	date := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+
+	// The upsert only writes when the new total fits, so concurrent submits cannot overshoot the quota
+	query := `
+		INSERT INTO call_quotas (user_id, day, calls)
+		SELECT $1::text, $2::date, $3::integer
+		WHERE $3::integer <= $4::integer
+		ON CONFLICT (user_id, day) DO UPDATE
+		SET calls = call_quotas.calls + EXCLUDED.calls, updated_at = now()
+		WHERE call_quotas.calls + EXCLUDED.calls <= $4::integer
+		RETURNING calls
+	`
+
+	var used int
+	err := s.pool.QueryRow(ctx, query, userID, date, n, limit).Scan(&used)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Refused: report the current count so callers can show what is left
+		err = s.pool.QueryRow(ctx, `SELECT calls FROM call_quotas WHERE user_id = $1 AND day = $2`, userID, date).Scan(&used)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, false, nil
+		}
+		return used, false, err
+	}
+	if err != nil {
+		return 0, false, err
 	}
 
-	return nil
+	return used, true, nil
 }
 
-// GetCallByUserID retrieves a call by user ID
-func GetCallByUserID(userID string) (*Call, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// RefundDailyQuota gives back n calls of the user's daily count
+func (s *PgCallStore) RefundDailyQuota(ctx context.Context, userID string, day time.Time, n int) error {
+	ctx, cancel := queryContext(ctx)
 	defer cancel()
 
+	day = day.UTC()
+	date := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+
 	query := `
-		SELECT id, user_id, call_id, model, year, zipcode, dealer_name, phone_number, 
-		       msrp, listing_price, status, is_available, deal_price, remarks,
-		       created_at, updated_at
+		UPDATE call_quotas
+		SET calls = GREATEST(calls - $3::integer, 0), updated_at = now()
+		WHERE user_id = $1 AND day = $2
+	`
+
+	_, err := s.pool.Exec(ctx, query, userID, date, n)
+	return err
+}
+
+// ReserveIdempotencyKey inserts a pending key, or returns the record already holding it
+func (s *PgCallStore) ReserveIdempotencyKey(ctx context.Context, userID, key, requestHash string, lockUntil time.Time) (*IdempotencyRecord, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	// The holder can be released or expire between the upsert and the read, so try again a few times
+	for attempt := 0; attempt < maxIdempotencyReserveAttempts; attempt++ {
+		// An expired key, including one whose request never completed, is free: it is taken over in place
+		tag, err := s.pool.Exec(ctx, `
+			INSERT INTO idempotency_keys (user_id, key, request_hash, expires_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (user_id, key) DO UPDATE
+			SET request_hash = EXCLUDED.request_hash, status_code = NULL, response_headers = NULL, response = NULL,
+				created_at = now(), expires_at = EXCLUDED.expires_at
+			WHERE idempotency_keys.expires_at < now()
+		`, userID, key, requestHash, lockUntil)
+		if err != nil {
+			return nil, err
+		}
+		if tag.RowsAffected() == 1 {
+			return nil, nil
+		}
+
+		record := &IdempotencyRecord{}
+		var statusCode *int
+		var headers []byte
+		err = s.pool.QueryRow(ctx, `
+			SELECT request_hash, status_code, response_headers, response
+			FROM idempotency_keys
+			WHERE user_id = $1 AND key = $2 AND expires_at >= now()
+		`, userID, key).Scan(&record.RequestHash, &statusCode, &headers, &record.Response)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if statusCode != nil {
+			record.Completed = true
+			record.StatusCode = *statusCode
+		}
+		if headers != nil {
+			if err := json.Unmarshal(headers, &record.Headers); err != nil {
+				return nil, fmt.Errorf("decode response headers: %w", err)
+			}
+		}
+
+		return record, nil
+	}
+
+	return nil, fmt.Errorf("idempotency key changed hands %d times while reserving it", maxIdempotencyReserveAttempts)
+}
+
+// CompleteIdempotencyKey records the response of the request holding a key
+func (s *PgCallStore) CompleteIdempotencyKey(ctx context.Context, userID, key string, statusCode int, headers map[string]string, response []byte, expiresAt time.Time) error {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	encodedHeaders, err := json.Marshal(headers)
+	if err != nil {
+		return fmt.Errorf("encode response headers: %w", err)
+	}
+
+	// jsonb is passed as text: the simple protocol would send []byte as a bytea literal
+	_, err = s.pool.Exec(ctx, `
+		UPDATE idempotency_keys
+		SET status_code = $3, response_headers = $4, response = $5, expires_at = $6
+		WHERE user_id = $1 AND key = $2
+	`, userID, key, statusCode, string(encodedHeaders), response, expiresAt)
+	return err
+}
+
+// ReleaseIdempotencyKey deletes a key
+func (s *PgCallStore) ReleaseIdempotencyKey(ctx context.Context, userID, key string) error {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	_, err := s.pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2`, userID, key)
+	return err
+}
+
+// DeleteExpiredIdempotencyKeys deletes every expired key
+func (s *PgCallStore) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	ctx, cancel := scanContext(ctx)
+	defer cancel()
+
+	tag, err := s.pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at < now()`)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// GetCallByUserID retrieves the most recent call for a user ID
+func (s *PgCallStore) GetCallByUserID(ctx context.Context, userID string) (*Call, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	query := `
+		SELECT ` + callColumns + `
 		FROM calls
 		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1
 	`
 
-	call := &Call{}
-	err := Pool.QueryRow(ctx, query, userID).Scan(
-		&call.ID, &call.UserID, &call.CallID, &call.Model, &call.Year, &call.ZipCode,
-		&call.DealerName, &call.PhoneNumber, &call.MSRP, &call.ListingPrice,
-		&call.Status, &call.IsAvailable, &call.DealPrice, &call.Remarks,
-		&call.CreatedAt, &call.UpdatedAt,
-	)
-
+	call, err := scanCall(s.pool.QueryRow(ctx, query, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCallNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -100,92 +413,111 @@ func GetCallByUserID(userID string) (*Call, error) {
 }
 
 // GetAllCalls retrieves all calls
-func GetAllCalls() ([]Call, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (s *PgCallStore) GetAllCalls(ctx context.Context) ([]Call, error) {
+	ctx, cancel := scanContext(ctx)
 	defer cancel()
 
 	query := `
-		SELECT id, user_id, call_id, model, year, zipcode, dealer_name, phone_number, 
-		       msrp, listing_price, status, is_available, deal_price, remarks,
-		       created_at, updated_at
+		SELECT ` + callColumns + `
 		FROM calls
 		ORDER BY created_at DESC
 	`
 
-	rows, err := Pool.Query(ctx, query)
+	rows, err := s.pool.Query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var calls []Call
-	for rows.Next() {
-		var call Call
-		err := rows.Scan(
-			&call.ID, &call.UserID, &call.CallID, &call.Model, &call.Year, &call.ZipCode,
-			&call.DealerName, &call.PhoneNumber, &call.MSRP, &call.ListingPrice,
-			&call.Status, &call.IsAvailable, &call.DealPrice, &call.Remarks,
-			&call.CreatedAt, &call.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		calls = append(calls, call)
-	}
-
-	return calls, rows.Err()
+	return scanCalls(rows)
 }
 
 // GetCallsByStatus retrieves calls filtered by status
-func GetCallsByStatus(status string) ([]Call, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (s *PgCallStore) GetCallsByStatus(ctx context.Context, status CallStatus) ([]Call, error) {
+	ctx, cancel := scanContext(ctx)
 	defer cancel()
 
 	query := `
-		SELECT id, user_id, call_id, model, year, zipcode, dealer_name, phone_number, 
-		       msrp, listing_price, status, is_available, deal_price, remarks,
-		       created_at, updated_at
+		SELECT ` + callColumns + `
 		FROM calls
 		WHERE status = $1
 		ORDER BY created_at DESC
 	`
 
-	rows, err := Pool.Query(ctx, query, status)
+	rows, err := s.pool.Query(ctx, query, status)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanCalls(rows)
+}
+
+// GetCallsForUser retrieves a user's calls, optionally filtered by status
+func (s *PgCallStore) GetCallsForUser(ctx context.Context, userID string, status CallStatus) ([]Call, error) {
+	ctx, cancel := scanContext(ctx)
+	defer cancel()
+
+	query := `
+		SELECT ` + callColumns + `
+		FROM calls
+		WHERE user_id = $1
+		  AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC
+	`
+
+	rows, err := s.pool.Query(ctx, query, userID, status)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanCalls(rows)
+}
+
+// CountCallsByStatus counts calls per status
+// Calls without a status are counted as 'pending', the column default
+func (s *PgCallStore) CountCallsByStatus(ctx context.Context) (map[string]int64, error) {
+	ctx, cancel := scanContext(ctx)
+	defer cancel()
+
+	query := `
+		SELECT COALESCE(status, 'pending'), COUNT(*)
+		FROM calls
+		GROUP BY 1
+	`
+
+	rows, err := s.pool.Query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var calls []Call
+	counts := make(map[string]int64)
 	for rows.Next() {
-		var call Call
-		err := rows.Scan(
-			&call.ID, &call.UserID, &call.CallID, &call.Model, &call.Year, &call.ZipCode,
-			&call.DealerName, &call.PhoneNumber, &call.MSRP, &call.ListingPrice,
-			&call.Status, &call.IsAvailable, &call.DealPrice, &call.Remarks,
-			&call.CreatedAt, &call.UpdatedAt,
-		)
-		if err != nil {
+		var status string
+		var count int64
+		if err := rows.Scan(&status, &count); err != nil {
 			return nil, err
 		}
-		calls = append(calls, call)
+		counts[status] = count
 	}
 
-	return calls, rows.Err()
+	return counts, rows.Err()
 }
 
 // GetBestDealForCar finds the best (lowest) deal price for a specific car
 // Returns the lowest deal_price and true if found, or 0 and false if no deals exist
-func GetBestDealForCar(model string, year int, zipcode string) (int64, bool, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// Calls recorded before make and condition were stored are treated as new Toyotas
+func (s *PgCallStore) GetBestDealForCar(ctx context.Context, vehicleMake, model string, year int, condition, zipcode string) (int64, bool, error) {
+	ctx, cancel := queryContext(ctx)
 	defer cancel()
 
 	query := `
 		SELECT MIN(deal_price) as best_price
 		FROM calls
-		WHERE model = $1 
-		  AND year = $2 
-		  AND zipcode = $3 
+		WHERE lower(COALESCE(make, 'toyota')) = lower($1)
+		  AND model = $2
+		  AND year = $3
+		  AND COALESCE(vehicle_condition, 'new') = $4
+		  AND zipcode = $5
 		  AND status = 'completed'
 		  AND is_available = true
 		  AND deal_price IS NOT NULL
@@ -193,7 +525,7 @@ func GetBestDealForCar(model string, year int, zipcode string) (int64, bool, err
 	`
 
 	var bestPrice *int64
-	err := Pool.QueryRow(ctx, query, model, year, zipcode).Scan(&bestPrice)
+	err := s.pool.QueryRow(ctx, query, vehicleMake, model, year, condition, zipcode).Scan(&bestPrice)
 
 	if err != nil {
 		return 0, false, err
@@ -206,3 +538,34 @@ func GetBestDealForCar(model string, year int, zipcode string) (int64, bool, err
 
 	return *bestPrice, true, nil
 }
+
+// scanCall scans one row selected with callColumns
+func scanCall(row pgx.Row) (*Call, error) {
+	call := &Call{}
+	err := row.Scan(
+		&call.ID, &call.UserID, &call.CallID, &call.Make, &call.Model, &call.Year, &call.Condition, &call.ZipCode,
+		&call.DealerName, &call.PhoneNumber, &call.MSRP, &call.ListingPrice,
+		&call.Status, &call.IsAvailable, &call.DealPrice, &call.Remarks, &call.TraceParent, &call.DispatchError,
+		&call.CreatedAt, &call.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return call, nil
+}
+
+// scanCalls scans and closes a result set selected with callColumns
+func scanCalls(rows pgx.Rows) ([]Call, error) {
+	defer rows.Close()
+
+	var calls []Call
+	for rows.Next() {
+		call, err := scanCall(rows)
+		if err != nil {
+			return nil, err
+		}
+		calls = append(calls, *call)
+	}
+
+	return calls, rows.Err()
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestIdempotencyKeyExpiry(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			userID := uuid.New().String()
+
+			// A completed key is replayed until it expires
+			if record, err := store.ReserveIdempotencyKey(ctx, userID, "live", "hash-1", time.Now().Add(time.Minute)); err != nil || record != nil {
+				t.Fatalf("first reserve = %+v, %v; want the key to be free", record, err)
+			}
+			err := store.CompleteIdempotencyKey(ctx, userID, "live", 202, map[string]string{"X-Quota-Remaining": "4"}, []byte(`{"success":true}`), time.Now().Add(time.Hour))
+			if err != nil {
+				t.Fatalf("CompleteIdempotencyKey: %v", err)
+			}
+			record, err := store.ReserveIdempotencyKey(ctx, userID, "live", "hash-2", time.Now().Add(time.Minute))
+			if err != nil || record == nil || !record.Completed || record.RequestHash != "hash-1" || record.Headers["X-Quota-Remaining"] != "4" {
+				t.Fatalf("second reserve = %+v, %v; want the completed record", record, err)
+			}
+
+			// An expired key, completed or not, is taken over by the next request without a sweep
+			if record, err := store.ReserveIdempotencyKey(ctx, userID, "expired", "hash-1", time.Now().Add(-time.Second)); err != nil || record != nil {
+				t.Fatalf("reserve = %+v, %v; want the key to be free", record, err)
+			}
+			if record, err := store.ReserveIdempotencyKey(ctx, userID, "expired", "hash-2", time.Now().Add(time.Minute)); err != nil || record != nil {
+				t.Fatalf("reserve after expiry = %+v, %v; want the key to be free", record, err)
+			}
+			record, err = store.ReserveIdempotencyKey(ctx, userID, "expired", "hash-3", time.Now().Add(time.Minute))
+			if err != nil || record == nil || record.Completed || record.RequestHash != "hash-2" {
+				t.Fatalf("reserve while held = %+v, %v; want the in-flight record of the new holder", record, err)
+			}
+
+			// The sweep drops expired keys and leaves live ones
+			if _, err := store.ReserveIdempotencyKey(ctx, userID, "stale", "hash-1", time.Now().Add(-time.Second)); err != nil {
+				t.Fatalf("reserve: %v", err)
+			}
+			deleted, err := store.DeleteExpiredIdempotencyKeys(ctx)
+			if err != nil || deleted < 1 {
+				t.Fatalf("DeleteExpiredIdempotencyKeys = %d, %v; want at least the stale key", deleted, err)
+			}
+			if record, err := store.ReserveIdempotencyKey(ctx, userID, "live", "hash-1", time.Now().Add(time.Minute)); err != nil || record == nil {
+				t.Fatalf("reserve after sweep = %+v, %v; want the live record kept", record, err)
+			}
+		})
+	}
+}
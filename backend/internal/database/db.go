@@ -3,19 +3,62 @@ package database
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
+	"hackutd2025/backend/internal/tracing"
+
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var Pool *pgxpool.Pool
 
-// InitDB initializes the database connection pool
-func InitDB(connectionString string) error {
-	ctx := context.Background()
+// OperationTimeouts caps how long a single database operation may take
+// The caller's context deadline still applies when it is sooner
+type OperationTimeouts struct {
+	// Query bounds single-row reads and writes
+	Query time.Duration
+	// Scan bounds multi-row reads and batch writes
+	Scan time.Duration
+}
+
+// Timeouts are the per-operation deadlines applied by every database call
+var Timeouts = OperationTimeouts{
+	Query: 5 * time.Second,
+	Scan:  10 * time.Second,
+}
+
+// queryContext derives the deadline for a single-row operation from the caller's context
+func queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, Timeouts.Query)
+}
 
+// scanContext derives the deadline for a multi-row operation from the caller's context
+func scanContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, Timeouts.Scan)
+}
+
+// PoolSettings sizes the connection pool and bounds connection lifetimes
+type PoolSettings struct {
+	MaxConns          int32
+	MinConns          int32
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+}
+
+// DefaultPoolSettings are the pool settings used when none are configured
+var DefaultPoolSettings = PoolSettings{
+	MaxConns:          25,
+	MinConns:          5,
+	MaxConnLifetime:   5 * time.Minute,
+	MaxConnIdleTime:   30 * time.Second,
+	HealthCheckPeriod: 1 * time.Minute,
+}
+
+// InitDB initializes the database connection pool
+func InitDB(ctx context.Context, connectionString string, settings PoolSettings) error {
 	// Configure connection pool
 	config, err := pgxpool.ParseConfig(connectionString)
 	if err != nil {
@@ -23,15 +66,18 @@ func InitDB(connectionString string) error {
 	}
 
 	// Set pool configuration
-	config.MaxConns = 25
-	config.MinConns = 5
-	config.MaxConnLifetime = 5 * time.Minute
-	config.MaxConnIdleTime = 30 * time.Second
-	config.HealthCheckPeriod = 1 * time.Minute
+	config.MaxConns = settings.MaxConns
+	config.MinConns = settings.MinConns
+	config.MaxConnLifetime = settings.MaxConnLifetime
+	config.MaxConnIdleTime = settings.MaxConnIdleTime
+	config.HealthCheckPeriod = settings.HealthCheckPeriod
 
 	// Disable prepared statement caching to avoid conflicts during concurrent operations
 	config.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeSimpleProtocol
 
+	// Trace every query; spans are dropped unless a tracer provider is installed
+	config.ConnConfig.Tracer = tracing.NewPgxTracer()
+
 	// Create connection pool
 	Pool, err = pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
@@ -43,8 +89,8 @@ func InitDB(connectionString string) error {
 		return fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	log.Println("✅ Database connection established")
-	log.Println("📋 Using existing Supabase schema (no table creation needed)")
+	slog.InfoContext(ctx, "database connection established",
+		slog.Int("max_conns", int(settings.MaxConns)), slog.Int("min_conns", int(settings.MinConns)))
 
 	return nil
 }
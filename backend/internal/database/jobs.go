@@ -0,0 +1,180 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// JobStatus is a step in a dispatch job's lifecycle
+type JobStatus string
+
+const (
+	// JobQueued is a job waiting for its next attempt
+	JobQueued JobStatus = "queued"
+	// JobRunning is a job a worker has claimed and holds a lease on
+	JobRunning JobStatus = "running"
+	// JobSucceeded is a job the agent service accepted
+	JobSucceeded JobStatus = "succeeded"
+	// JobDead is a job that was rejected or ran out of attempts; its calls are dispatch_failed
+	JobDead JobStatus = "dead"
+)
+
+// NewCallJob is the agent service request that dispatches a batch of calls
+type NewCallJob struct {
+	// Payload is the JSON body posted to the agent service
+	Payload []byte
+	// TraceParent is the W3C traceparent of the submission that queued the job, if any
+	TraceParent string
+	// RequestID is the X-Request-ID of the submission that queued the job, if any
+	RequestID string
+}
+
+// CallJob is a dispatch job claimed by a worker
+type CallJob struct {
+	ID      int64
+	CallIDs []string
+	Payload []byte
+	// Attempts counts the times the job was claimed, including the current one
+	Attempts    int
+	TraceParent *string
+	RequestID   *string
+}
+
+// EnqueueCalls inserts call records and the job that dispatches them in a single transaction
+func (s *PgCallStore) EnqueueCalls(ctx context.Context, calls []NewCall, job NewCallJob) (int64, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	callIDs := make([]string, len(calls))
+	for i, call := range calls {
+		callIDs[i] = call.CallID
+	}
+
+	query := `
+		INSERT INTO call_jobs (call_ids, payload, trace_parent, request_id)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''))
+		RETURNING id
+	`
+
+	var jobID int64
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		if err := insertCallsTx(ctx, tx, calls); err != nil {
+			return err
+		}
+		// The payload is passed as text: the simple protocol would send []byte as a bytea literal, which jsonb rejects
+		return tx.QueryRow(ctx, query, callIDs, string(job.Payload), job.TraceParent, job.RequestID).Scan(&jobID)
+	})
+	return jobID, duplicateCallError(err)
+}
+
+// ClaimCallJobs leases up to limit due jobs, skipping rows other workers have locked
+// Running jobs whose lease ran out, because their worker crashed or stalled, are claimed again
+func (s *PgCallStore) ClaimCallJobs(ctx context.Context, limit int, lease time.Duration) ([]CallJob, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE call_jobs
+		SET status = 'running', attempts = attempts + 1,
+			locked_until = now() + make_interval(secs => $2), updated_at = now()
+		WHERE id IN (
+			SELECT id FROM call_jobs
+			WHERE (status = 'queued' AND run_at <= now())
+			   OR (status = 'running' AND locked_until <= now())
+			ORDER BY run_at, id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, call_ids, payload, attempts, trace_parent, request_id
+	`
+
+	rows, err := s.pool.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (CallJob, error) {
+		var job CallJob
+		err := row.Scan(&job.ID, &job.CallIDs, &job.Payload, &job.Attempts, &job.TraceParent, &job.RequestID)
+		return job, err
+	})
+}
+
+// RetryCallJob returns a claimed job to the queue for another attempt at runAt
+func (s *PgCallStore) RetryCallJob(ctx context.Context, job CallJob, runAt time.Time, lastError string) error {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	// A worker whose lease ran out and was claimed again must not requeue the new holder's attempt
+	query := `
+		UPDATE call_jobs
+		SET status = 'queued', run_at = $3, last_error = $4, locked_until = NULL, updated_at = now()
+		WHERE id = $1 AND status = 'running' AND attempts = $2
+	`
+
+	tag, err := s.pool.Exec(ctx, query, job.ID, job.Attempts, runAt, lastError)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrJobLeaseLost
+	}
+	return nil
+}
+
+// CompleteCallJob marks a job succeeded and moves its pending calls to 'dispatched'
+func (s *PgCallStore) CompleteCallJob(ctx context.Context, job CallJob) error {
+	update := `
+		UPDATE calls
+		SET status = $2, updated_at = now()
+		WHERE call_id = ANY($1)
+	`
+
+	return s.finishCallJob(ctx, job, JobSucceeded, "", StatusDispatched, "accepted by agent service", update)
+}
+
+// FailCallJob dead-letters a job and moves its pending calls to 'dispatch_failed'
+func (s *PgCallStore) FailCallJob(ctx context.Context, job CallJob, lastError string) error {
+	update := `
+		UPDATE calls
+		SET status = $2, dispatch_error = $3, updated_at = now()
+		WHERE call_id = ANY($1)
+	`
+
+	return s.finishCallJob(ctx, job, JobDead, lastError, StatusDispatchFailed, lastError, update, lastError)
+}
+
+// finishCallJob records a job's final status and moves its pending calls to status to, in one transaction
+// Calls that are no longer pending are left as they are, so the job's outcome is recorded either way
+func (s *PgCallStore) finishCallJob(ctx context.Context, job CallJob, status JobStatus, lastError string, to CallStatus, reason, update string, args ...any) error {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	// Only the worker holding the current attempt may record its outcome
+	query := `
+		UPDATE call_jobs
+		SET status = $3, last_error = COALESCE(NULLIF($4, ''), last_error), locked_until = NULL, updated_at = now()
+		WHERE id = $1 AND status = 'running' AND attempts = $2
+	`
+
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, query, job.ID, job.Attempts, status, lastError)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrJobLeaseLost
+		}
+
+		rows, err := tx.Query(ctx, `SELECT call_id FROM calls WHERE call_id = ANY($1) AND status = $2 FOR UPDATE`, job.CallIDs, StatusPending)
+		if err != nil {
+			return err
+		}
+		pending, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil || len(pending) == 0 {
+			return err
+		}
+		return transitionCallsTx(ctx, tx, pending, to, reason, update, args...)
+	})
+}
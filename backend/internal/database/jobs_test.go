@@ -0,0 +1,165 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// testStores returns a MemoryCallStore, and a PgCallStore on a migrated database when TEST_DATABASE_URL is set
+func testStores(t *testing.T) map[string]CallStore {
+	t.Helper()
+	stores := map[string]CallStore{"memory": NewMemoryCallStore()}
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		return stores
+	}
+	ctx := context.Background()
+	if err := InitDB(ctx, url, DefaultPoolSettings); err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	t.Cleanup(CloseDB)
+	if _, err := MigrateUp(ctx); err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+	stores["postgres"] = NewPgCallStore(Pool)
+	return stores
+}
+
+func TestCallJobRoundTrip(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			callID := uuid.New().String()
+			payload := []byte(`[{"user_id": "` + callID + `", "dealer_name": "Toyota of Lewisville"}]`)
+
+			jobID, err := store.EnqueueCalls(ctx, []NewCall{{UserID: "user-1", CallID: callID, Model: "RAV4", Year: 2024}}, NewCallJob{
+				Payload:     payload,
+				TraceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+				RequestID:   "req-1",
+			})
+			if err != nil {
+				t.Fatalf("EnqueueCalls: %v", err)
+			}
+
+			jobs, err := store.ClaimCallJobs(ctx, 100, time.Minute)
+			if err != nil {
+				t.Fatalf("ClaimCallJobs: %v", err)
+			}
+			var job *CallJob
+			for i := range jobs {
+				if jobs[i].ID == jobID {
+					job = &jobs[i]
+				}
+			}
+			if job == nil {
+				t.Fatalf("job %d was not claimed", jobID)
+			}
+
+			// jsonb may reformat the payload, so compare it decoded
+			var got, want any
+			if err := json.Unmarshal(job.Payload, &got); err != nil {
+				t.Fatalf("claimed payload %q: %v", job.Payload, err)
+			}
+			json.Unmarshal(payload, &want)
+			if gotJSON, wantJSON := mustMarshal(t, got), mustMarshal(t, want); gotJSON != wantJSON {
+				t.Errorf("payload = %s, want %s", gotJSON, wantJSON)
+			}
+			if len(job.CallIDs) != 1 || job.CallIDs[0] != callID {
+				t.Errorf("call IDs = %v, want [%s]", job.CallIDs, callID)
+			}
+			if job.Attempts != 1 {
+				t.Errorf("attempts = %d, want 1", job.Attempts)
+			}
+			if job.TraceParent == nil || *job.TraceParent != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+				t.Errorf("trace parent = %v", job.TraceParent)
+			}
+			if job.RequestID == nil || *job.RequestID != "req-1" {
+				t.Errorf("request ID = %v, want req-1", job.RequestID)
+			}
+
+			if err := store.CompleteCallJob(ctx, *job); err != nil {
+				t.Fatalf("CompleteCallJob: %v", err)
+			}
+			transitions, err := store.GetCallTransitions(ctx, callID)
+			if err != nil {
+				t.Fatalf("GetCallTransitions: %v", err)
+			}
+			if last := transitions[len(transitions)-1].To; last != StatusDispatched {
+				t.Errorf("status = %s, want dispatched", last)
+			}
+		})
+	}
+}
+
+func TestCallJobLeaseFence(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			callID := uuid.New().String()
+			jobID, err := store.EnqueueCalls(ctx, []NewCall{{UserID: "user-1", CallID: callID}}, NewCallJob{Payload: []byte(`[]`)})
+			if err != nil {
+				t.Fatalf("EnqueueCalls: %v", err)
+			}
+
+			// The first claim's lease runs out at once, so a second worker claims the job again
+			stale := claimJob(t, store, jobID, 0)
+			current := claimJob(t, store, jobID, time.Minute)
+			if current.Attempts != stale.Attempts+1 {
+				t.Fatalf("attempts = %d then %d, want consecutive", stale.Attempts, current.Attempts)
+			}
+
+			if err := store.RetryCallJob(ctx, stale, time.Now(), "stale"); !errors.Is(err, ErrJobLeaseLost) {
+				t.Errorf("RetryCallJob = %v, want %v", err, ErrJobLeaseLost)
+			}
+			if err := store.FailCallJob(ctx, stale, "stale"); !errors.Is(err, ErrJobLeaseLost) {
+				t.Errorf("FailCallJob = %v, want %v", err, ErrJobLeaseLost)
+			}
+			if err := store.CompleteCallJob(ctx, stale); !errors.Is(err, ErrJobLeaseLost) {
+				t.Errorf("CompleteCallJob = %v, want %v", err, ErrJobLeaseLost)
+			}
+			if transitions, err := store.GetCallTransitions(ctx, callID); err != nil || len(transitions) != 1 {
+				t.Fatalf("transitions = %+v, %v; want the call still only created", transitions, err)
+			}
+
+			if err := store.CompleteCallJob(ctx, current); err != nil {
+				t.Fatalf("CompleteCallJob with the current claim: %v", err)
+			}
+			// A finished job is no longer running, so even its holder cannot record a second outcome
+			if err := store.FailCallJob(ctx, current, "late"); !errors.Is(err, ErrJobLeaseLost) {
+				t.Errorf("FailCallJob after completion = %v, want %v", err, ErrJobLeaseLost)
+			}
+		})
+	}
+}
+
+// claimJob claims due jobs until jobID is among them, failing the test if it is not due
+func claimJob(t *testing.T, store CallStore, jobID int64, lease time.Duration) CallJob {
+	t.Helper()
+	jobs, err := store.ClaimCallJobs(context.Background(), 100, lease)
+	if err != nil {
+		t.Fatalf("ClaimCallJobs: %v", err)
+	}
+	for _, job := range jobs {
+		if job.ID == jobID {
+			return job
+		}
+	}
+	t.Fatalf("job %d was not claimed", jobID)
+	return CallJob{}
+}
+
+func mustMarshal(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return string(b)
+}
@@ -0,0 +1,169 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"hackutd2025/backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ListingSnapshot represents the latest known state of a listing, keyed by VIN
+type ListingSnapshot struct {
+	VIN              string    `json:"vin"`
+	Make             string    `json:"make"`
+	Model            string    `json:"model"`
+	Year             int       `json:"year"`
+	Trim             string    `json:"trim"`
+	VehicleCondition string    `json:"vehicle_condition"`
+	Msrp             int       `json:"msrp"`
+	DealerCarfaxID   string    `json:"dealer_carfax_id"`
+	DealerName       string    `json:"dealer_name"`
+	FirstSeen        string    `json:"first_seen"`
+	FirstObservedAt  time.Time `json:"first_observed_at"`
+	LastObservedAt   time.Time `json:"last_observed_at"`
+}
+
+// ListingObservation represents the prices seen for a listing at one point in time
+type ListingObservation struct {
+	CurrentPrice int       `json:"current_price"`
+	ListPrice    int       `json:"list_price"`
+	FollowCount  int       `json:"follow_count"`
+	ObservedAt   time.Time `json:"observed_at"`
+}
+
+// PgListingStore is the Postgres-backed ListingStore
+type PgListingStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPgListingStore creates a ListingStore over a pgx connection pool
+func NewPgListingStore(pool *pgxpool.Pool) *PgListingStore {
+	return &PgListingStore{pool: pool}
+}
+
+// UpsertListings stores the latest snapshot of each listing and records an observation of its prices
+// Listings without a VIN are skipped
+func (s *PgListingStore) UpsertListings(ctx context.Context, listings []models.Listing) error {
+	ctx, cancel := scanContext(ctx)
+	defer cancel()
+
+	upsertQuery := `
+		INSERT INTO listings (vin, make, model, year, trim, vehicle_condition, msrp,
+		                      dealer_carfax_id, dealer_name, first_seen, first_observed_at, last_observed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $11)
+		ON CONFLICT (vin) DO UPDATE
+		SET make = EXCLUDED.make, model = EXCLUDED.model, year = EXCLUDED.year, trim = EXCLUDED.trim,
+		    vehicle_condition = EXCLUDED.vehicle_condition, msrp = EXCLUDED.msrp,
+		    dealer_carfax_id = EXCLUDED.dealer_carfax_id, dealer_name = EXCLUDED.dealer_name,
+		    first_seen = EXCLUDED.first_seen, last_observed_at = EXCLUDED.last_observed_at
+	`
+
+	observationQuery := `
+		INSERT INTO listing_observations (vin, current_price, list_price, follow_count, observed_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	observedAt := time.Now()
+	batch := &pgx.Batch{}
+	for _, listing := range sortedByVIN(listings) {
+		if listing.VIN == "" {
+			continue
+		}
+
+		batch.Queue(upsertQuery, listing.VIN, listing.Make, listing.Model, listing.Year, listing.Trim,
+			listing.VehicleCondition, listing.Msrp, listing.Dealer.CarfaxID, listing.Dealer.Name,
+			listing.FirstSeen, observedAt)
+		batch.Queue(observationQuery, listing.VIN, listing.CurrentPrice, listing.ListPrice,
+			listing.FollowCount, observedAt)
+	}
+
+	if batch.Len() == 0 {
+		return nil
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to store listings: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// sortedByVIN returns a copy of listings ordered by VIN
+// Concurrent upserts then lock overlapping rows in the same order and cannot deadlock
+func sortedByVIN(listings []models.Listing) []models.Listing {
+	sorted := append([]models.Listing(nil), listings...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].VIN < sorted[j].VIN
+	})
+	return sorted
+}
+
+// GetListingSnapshot retrieves the stored snapshot for a VIN
+func (s *PgListingStore) GetListingSnapshot(ctx context.Context, vin string) (*ListingSnapshot, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	query := `
+		SELECT vin, make, model, year, trim, vehicle_condition, msrp, dealer_carfax_id, dealer_name,
+		       first_seen, first_observed_at, last_observed_at
+		FROM listings
+		WHERE vin = $1
+	`
+
+	snapshot := &ListingSnapshot{}
+	err := s.pool.QueryRow(ctx, query, vin).Scan(
+		&snapshot.VIN, &snapshot.Make, &snapshot.Model, &snapshot.Year, &snapshot.Trim,
+		&snapshot.VehicleCondition, &snapshot.Msrp, &snapshot.DealerCarfaxID, &snapshot.DealerName,
+		&snapshot.FirstSeen, &snapshot.FirstObservedAt, &snapshot.LastObservedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrListingNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// GetListingObservations retrieves the price history for a VIN, oldest first
+func (s *PgListingStore) GetListingObservations(ctx context.Context, vin string) ([]ListingObservation, error) {
+	ctx, cancel := scanContext(ctx)
+	defer cancel()
+
+	query := `
+		SELECT current_price, list_price, follow_count, observed_at
+		FROM listing_observations
+		WHERE vin = $1
+		ORDER BY observed_at ASC
+	`
+
+	rows, err := s.pool.Query(ctx, query, vin)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var observations []ListingObservation
+	for rows.Next() {
+		var observation ListingObservation
+		if err := rows.Scan(&observation.CurrentPrice, &observation.ListPrice, &observation.FollowCount, &observation.ObservedAt); err != nil {
+			return nil, err
+		}
+		observations = append(observations, observation)
+	}
+
+	return observations, rows.Err()
+}
@@ -0,0 +1,21 @@
+package database
+
+import (
+	"testing"
+
+	"hackutd2025/backend/internal/models"
+)
+
+func TestSortedByVIN(t *testing.T) {
+	listings := []models.Listing{{VIN: "C"}, {VIN: "A"}, {VIN: "B"}}
+
+	sorted := sortedByVIN(listings)
+	for i, want := range []string{"A", "B", "C"} {
+		if sorted[i].VIN != want {
+			t.Errorf("sorted[%d] = %s, want %s", i, sorted[i].VIN, want)
+		}
+	}
+	if listings[0].VIN != "C" {
+		t.Errorf("input was reordered")
+	}
+}
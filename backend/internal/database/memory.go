@@ -0,0 +1,624 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryCallStore is an in-memory CallStore with the same semantics as PgCallStore
+// It is safe for concurrent use and intended for tests, demos and running without Postgres
+type MemoryCallStore struct {
+	mu     sync.RWMutex
+	calls  []Call
+	nextID int64
+	now    func() time.Time
+	// quotas counts calls per user per UTC day, keyed by user ID and date
+	quotas map[quotaKey]int
+	// idempotency holds Idempotency-Key records, keyed by user ID and key
+	idempotency map[idempotencyKey]memoryIdempotencyRecord
+	// transitions holds each call's status changes, keyed by call ID
+	transitions map[string][]CallTransition
+	// jobs holds dispatch jobs in ID order, starting at 1
+	jobs []memoryCallJob
+}
+
+// memoryCallJob is a CallJob with its queue state
+type memoryCallJob struct {
+	CallJob
+	status      JobStatus
+	runAt       time.Time
+	lockedUntil time.Time
+	lastError   string
+}
+
+// idempotencyKey identifies one user's Idempotency-Key
+type idempotencyKey struct {
+	userID string
+	key    string
+}
+
+// memoryIdempotencyRecord is an IdempotencyRecord with its expiry
+type memoryIdempotencyRecord struct {
+	IdempotencyRecord
+	expiresAt time.Time
+}
+
+// quotaKey identifies a user's call count for one UTC day
+type quotaKey struct {
+	userID string
+	day    string
+}
+
+// NewMemoryCallStore creates an empty in-memory CallStore
+func NewMemoryCallStore() *MemoryCallStore {
+	return &MemoryCallStore{
+		nextID:      1,
+		now:         time.Now,
+		quotas:      make(map[quotaKey]int),
+		idempotency: make(map[idempotencyKey]memoryIdempotencyRecord),
+		transitions: make(map[string][]CallTransition),
+	}
+}
+
+// CreateCall implements CallStore
+func (s *MemoryCallStore) CreateCall(ctx context.Context, call NewCall) error {
+	return s.CreateCalls(ctx, []NewCall{call})
+}
+
+// CreateCalls implements CallStore
+func (s *MemoryCallStore) CreateCalls(ctx context.Context, calls []NewCall) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.createCallsLocked(calls)
+}
+
+// createCallsLocked stores calls in the 'pending' status; the caller holds s.mu
+func (s *MemoryCallStore) createCallsLocked(calls []NewCall) error {
+	// Mirror the unique index on call_id, checking every call before storing any
+	taken := make(map[string]bool, len(s.calls)+len(calls))
+	for _, existing := range s.calls {
+		if existing.CallID != nil {
+			taken[*existing.CallID] = true
+		}
+	}
+	for _, call := range calls {
+		if taken[call.CallID] {
+			return ErrDuplicateCallID
+		}
+		taken[call.CallID] = true
+	}
+
+	now := s.now()
+	for _, call := range calls {
+		s.calls = append(s.calls, Call{
+			ID:           s.nextID,
+			UserID:       &call.UserID,
+			CallID:       &call.CallID,
+			Make:         &call.Make,
+			Model:        &call.Model,
+			Year:         &call.Year,
+			Condition:    &call.Condition,
+			ZipCode:      &call.ZipCode,
+			DealerName:   &call.DealerName,
+			PhoneNumber:  &call.PhoneNumber,
+			MSRP:         &call.MSRP,
+			ListingPrice: &call.ListingPrice,
+			Status:       statusPtr(StatusPending),
+			TraceParent:  nullableString(call.TraceParent),
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		})
+		s.nextID++
+		s.transitions[call.CallID] = []CallTransition{{To: StatusPending, Reason: stringPtr("created"), At: now}}
+	}
+
+	return nil
+}
+
+// EnqueueCalls implements CallStore
+func (s *MemoryCallStore) EnqueueCalls(ctx context.Context, calls []NewCall, job NewCallJob) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.createCallsLocked(calls); err != nil {
+		return 0, err
+	}
+
+	callIDs := make([]string, len(calls))
+	for i, call := range calls {
+		callIDs[i] = call.CallID
+	}
+	s.jobs = append(s.jobs, memoryCallJob{
+		CallJob: CallJob{
+			ID:          int64(len(s.jobs) + 1),
+			CallIDs:     callIDs,
+			Payload:     append([]byte(nil), job.Payload...),
+			TraceParent: nullableString(job.TraceParent),
+			RequestID:   nullableString(job.RequestID),
+		},
+		status: JobQueued,
+		runAt:  s.now(),
+	})
+	return int64(len(s.jobs)), nil
+}
+
+// ClaimCallJobs implements CallStore
+func (s *MemoryCallStore) ClaimCallJobs(ctx context.Context, limit int, lease time.Duration) ([]CallJob, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	due := make([]*memoryCallJob, 0, limit)
+	for i := range s.jobs {
+		job := &s.jobs[i]
+		if (job.status == JobQueued && !job.runAt.After(now)) || (job.status == JobRunning && !job.lockedUntil.After(now)) {
+			due = append(due, job)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].runAt.Before(due[j].runAt)
+	})
+
+	claimed := make([]CallJob, 0, min(limit, len(due)))
+	for _, job := range due[:min(limit, len(due))] {
+		job.status = JobRunning
+		job.Attempts++
+		job.lockedUntil = now.Add(lease)
+		claimed = append(claimed, job.CallJob)
+	}
+	return claimed, nil
+}
+
+// RetryCallJob implements CallStore
+func (s *MemoryCallStore) RetryCallJob(ctx context.Context, job CallJob, runAt time.Time, lastError string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.heldJobLocked(job)
+	if err != nil {
+		return err
+	}
+	stored.status = JobQueued
+	stored.runAt = runAt
+	stored.lockedUntil = time.Time{}
+	stored.lastError = lastError
+	return nil
+}
+
+// heldJobLocked returns the stored job if it is still running on the attempt job was claimed for, or ErrJobLeaseLost
+func (s *MemoryCallStore) heldJobLocked(job CallJob) (*memoryCallJob, error) {
+	if job.ID < 1 || job.ID > int64(len(s.jobs)) {
+		return nil, ErrJobLeaseLost
+	}
+	stored := &s.jobs[job.ID-1]
+	if stored.status != JobRunning || stored.Attempts != job.Attempts {
+		return nil, ErrJobLeaseLost
+	}
+	return stored, nil
+}
+
+// CompleteCallJob implements CallStore
+func (s *MemoryCallStore) CompleteCallJob(ctx context.Context, job CallJob) error {
+	return s.finishCallJob(ctx, job, JobSucceeded, "", StatusDispatched, "accepted by agent service", func(*Call) {})
+}
+
+// FailCallJob implements CallStore
+func (s *MemoryCallStore) FailCallJob(ctx context.Context, job CallJob, lastError string) error {
+	return s.finishCallJob(ctx, job, JobDead, lastError, StatusDispatchFailed, lastError, func(call *Call) {
+		call.DispatchError = stringPtr(lastError)
+	})
+}
+
+// finishCallJob records a job's final status and moves its calls that are still pending to status to
+func (s *MemoryCallStore) finishCallJob(ctx context.Context, job CallJob, status JobStatus, lastError string, to CallStatus, reason string, update func(*Call)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.heldJobLocked(job)
+	if err != nil {
+		return err
+	}
+	stored.status = status
+	stored.lockedUntil = time.Time{}
+	if lastError != "" {
+		stored.lastError = lastError
+	}
+
+	pending := make(map[string]bool, len(job.CallIDs))
+	for _, call := range s.calls {
+		if call.CallID != nil && valueOr(call.Status, StatusPending) == StatusPending {
+			pending[*call.CallID] = true
+		}
+	}
+	var callIDs []string
+	for _, callID := range job.CallIDs {
+		if pending[callID] {
+			callIDs = append(callIDs, callID)
+		}
+	}
+	if len(callIDs) == 0 {
+		return nil
+	}
+	return s.transitionCallsLocked(callIDs, to, reason, update)
+}
+
+// TransitionCalls implements CallStore
+func (s *MemoryCallStore) TransitionCalls(ctx context.Context, callIDs []string, to CallStatus, reason string) error {
+	return s.transitionCalls(ctx, callIDs, to, reason, func(*Call) {})
+}
+
+// UpdateCallResult implements CallStore
+func (s *MemoryCallStore) UpdateCallResult(ctx context.Context, callID string, result CallResult) error {
+	if !result.Status.IsResult() {
+		return fmt.Errorf("%w: %s is not a call result", ErrInvalidTransition, result.Status)
+	}
+
+	return s.transitionCalls(ctx, []string{callID}, result.Status, "result reported", func(call *Call) {
+		price := int64(result.DealPrice)
+		call.IsAvailable = &result.IsAvailable
+		call.DealPrice = &price
+		call.Remarks = &result.Remarks
+	})
+}
+
+// transitionCalls moves calls to status to after checking every move, applying update to each moved call
+func (s *MemoryCallStore) transitionCalls(ctx context.Context, callIDs []string, to CallStatus, reason string, update func(*Call)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.transitionCallsLocked(callIDs, to, reason, update)
+}
+
+// transitionCallsLocked is transitionCalls for a caller holding s.mu
+func (s *MemoryCallStore) transitionCallsLocked(callIDs []string, to CallStatus, reason string, update func(*Call)) error {
+	index := make(map[string]int, len(s.calls))
+	for i, call := range s.calls {
+		if call.CallID != nil {
+			index[*call.CallID] = i
+		}
+	}
+
+	// Check every call before changing any, as the Postgres transaction does
+	for _, callID := range callIDs {
+		i, ok := index[callID]
+		if !ok {
+			return ErrCallNotFound
+		}
+		if err := checkTransition(callID, valueOr(s.calls[i].Status, StatusPending), to); err != nil {
+			return err
+		}
+	}
+
+	now := s.now()
+	for _, callID := range callIDs {
+		call := &s.calls[index[callID]]
+		from := valueOr(call.Status, StatusPending)
+		update(call)
+		call.Status = statusPtr(to)
+		call.UpdatedAt = now
+		s.transitions[callID] = append(s.transitions[callID], CallTransition{
+			From: &from, To: to, Reason: nullableString(reason), At: now,
+		})
+	}
+
+	return nil
+}
+
+// GetCallTransitions implements CallStore
+func (s *MemoryCallStore) GetCallTransitions(ctx context.Context, callID string) ([]CallTransition, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	transitions, ok := s.transitions[callID]
+	if !ok {
+		return nil, ErrCallNotFound
+	}
+	return append([]CallTransition(nil), transitions...), nil
+}
+
+// GetCallTraceParent implements CallStore
+func (s *MemoryCallStore) GetCallTraceParent(ctx context.Context, callID string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	calls := s.filter(func(call Call) bool {
+		return call.CallID != nil && *call.CallID == callID
+	})
+	if len(calls) == 0 {
+		return "", ErrCallNotFound
+	}
+	return valueOr(calls[0].TraceParent, ""), nil
+}
+
+// ConsumeDailyQuota implements CallStore
+func (s *MemoryCallStore) ConsumeDailyQuota(ctx context.Context, userID string, day time.Time, n, limit int) (int, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := quotaKey{userID: userID, day: day.UTC().Format(time.DateOnly)}
+	used := s.quotas[key]
+	if used+n > limit {
+		return used, false, nil
+	}
+	s.quotas[key] = used + n
+
+	return used + n, true, nil
+}
+
+// RefundDailyQuota implements CallStore
+func (s *MemoryCallStore) RefundDailyQuota(ctx context.Context, userID string, day time.Time, n int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := quotaKey{userID: userID, day: day.UTC().Format(time.DateOnly)}
+	if used, ok := s.quotas[key]; ok {
+		s.quotas[key] = max(used-n, 0)
+	}
+
+	return nil
+}
+
+// ReserveIdempotencyKey implements CallStore
+func (s *MemoryCallStore) ReserveIdempotencyKey(ctx context.Context, userID, key, requestHash string, lockUntil time.Time) (*IdempotencyRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// An expired key, including one whose request never completed, is free to be claimed again
+	id := idempotencyKey{userID: userID, key: key}
+	if existing, ok := s.idempotency[id]; ok && !s.now().After(existing.expiresAt) {
+		record := existing.IdempotencyRecord
+		record.Headers = maps.Clone(record.Headers)
+		return &record, nil
+	}
+
+	s.idempotency[id] = memoryIdempotencyRecord{
+		IdempotencyRecord: IdempotencyRecord{RequestHash: requestHash},
+		expiresAt:         lockUntil,
+	}
+	return nil, nil
+}
+
+// CompleteIdempotencyKey implements CallStore
+func (s *MemoryCallStore) CompleteIdempotencyKey(ctx context.Context, userID, key string, statusCode int, headers map[string]string, response []byte, expiresAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := idempotencyKey{userID: userID, key: key}
+	record, ok := s.idempotency[id]
+	if !ok {
+		return nil
+	}
+	record.Completed = true
+	record.StatusCode = statusCode
+	record.Headers = maps.Clone(headers)
+	record.Response = append([]byte(nil), response...)
+	record.expiresAt = expiresAt
+	s.idempotency[id] = record
+
+	return nil
+}
+
+// ReleaseIdempotencyKey implements CallStore
+func (s *MemoryCallStore) ReleaseIdempotencyKey(ctx context.Context, userID, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.idempotency, idempotencyKey{userID: userID, key: key})
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys implements CallStore
+func (s *MemoryCallStore) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	now := s.now()
+	for id, record := range s.idempotency {
+		if now.After(record.expiresAt) {
+			delete(s.idempotency, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// GetCallByUserID implements CallStore
+func (s *MemoryCallStore) GetCallByUserID(ctx context.Context, userID string) (*Call, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	calls := s.filter(func(call Call) bool {
+		return call.UserID != nil && *call.UserID == userID
+	})
+
+	if len(calls) == 0 {
+		return nil, ErrCallNotFound
+	}
+
+	return &calls[0], nil
+}
+
+// GetAllCalls implements CallStore
+func (s *MemoryCallStore) GetAllCalls(ctx context.Context) ([]Call, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return s.filter(func(Call) bool { return true }), nil
+}
+
+// GetCallsByStatus implements CallStore
+func (s *MemoryCallStore) GetCallsByStatus(ctx context.Context, status CallStatus) ([]Call, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return s.filter(func(call Call) bool {
+		return call.Status != nil && *call.Status == status
+	}), nil
+}
+
+// GetCallsForUser implements CallStore
+func (s *MemoryCallStore) GetCallsForUser(ctx context.Context, userID string, status CallStatus) ([]Call, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return s.filter(func(call Call) bool {
+		return call.UserID != nil && *call.UserID == userID &&
+			(status == "" || (call.Status != nil && *call.Status == status))
+	}), nil
+}
+
+// CountCallsByStatus implements CallStore
+func (s *MemoryCallStore) CountCallsByStatus(ctx context.Context) (map[string]int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[string]int64)
+	for _, call := range s.calls {
+		counts[string(valueOr(call.Status, StatusPending))]++
+	}
+	return counts, nil
+}
+
+// GetBestDealForCar implements CallStore
+func (s *MemoryCallStore) GetBestDealForCar(ctx context.Context, vehicleMake, model string, year int, condition, zipcode string) (int64, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, false, err
+	}
+
+	calls := s.filter(func(call Call) bool {
+		return strings.EqualFold(valueOr(call.Make, "toyota"), vehicleMake) &&
+			valueOr(call.Model, "") == model &&
+			call.Year != nil && *call.Year == year &&
+			valueOr(call.Condition, "new") == condition &&
+			valueOr(call.ZipCode, "") == zipcode &&
+			valueOr(call.Status, "") == StatusCompleted &&
+			call.IsAvailable != nil && *call.IsAvailable &&
+			call.DealPrice != nil && *call.DealPrice > 0
+	})
+
+	var best int64
+	for _, call := range calls {
+		if best == 0 || *call.DealPrice < best {
+			best = *call.DealPrice
+		}
+	}
+
+	return best, best > 0, nil
+}
+
+// filter returns copies of the matching calls, newest first
+func (s *MemoryCallStore) filter(match func(Call) bool) []Call {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var calls []Call
+	for _, call := range s.calls {
+		if match(call) {
+			calls = append(calls, call)
+		}
+	}
+
+	// Ties on created_at fall back to insertion order, newest first
+	sort.SliceStable(calls, func(i, j int) bool {
+		if !calls[i].CreatedAt.Equal(calls[j].CreatedAt) {
+			return calls[i].CreatedAt.After(calls[j].CreatedAt)
+		}
+		return calls[i].ID > calls[j].ID
+	})
+
+	return calls
+}
+
+// stringPtr returns a pointer to a copy of value
+func stringPtr(value string) *string {
+	return &value
+}
+
+// nullableString treats an empty string as NULL, as CreateCall does
+func nullableString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// statusPtr returns a pointer to a copy of status
+func statusPtr(status CallStatus) *CallStatus {
+	return &status
+}
+
+// valueOr dereferences value, or returns fallback when it is nil
+func valueOr[T any](value *T, fallback T) T {
+	if value == nil {
+		return fallback
+	}
+	return *value
+}
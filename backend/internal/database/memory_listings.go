@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"hackutd2025/backend/internal/models"
+)
+
+// MemoryListingStore is an in-memory ListingStore with the same semantics as PgListingStore
+// It is safe for concurrent use and intended for tests, demos and running without Postgres
+type MemoryListingStore struct {
+	mu  sync.RWMutex
+	now func() time.Time
+	// snapshots holds the latest state of each listing, keyed by VIN
+	snapshots map[string]ListingSnapshot
+	// observations holds each listing's price observations in the order they were recorded, keyed by VIN
+	observations map[string][]ListingObservation
+}
+
+// NewMemoryListingStore creates an empty in-memory ListingStore
+func NewMemoryListingStore() *MemoryListingStore {
+	return &MemoryListingStore{
+		now:          time.Now,
+		snapshots:    make(map[string]ListingSnapshot),
+		observations: make(map[string][]ListingObservation),
+	}
+}
+
+// UpsertListings implements ListingStore
+func (s *MemoryListingStore) UpsertListings(ctx context.Context, listings []models.Listing) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	observedAt := s.now()
+	for _, listing := range sortedByVIN(listings) {
+		if listing.VIN == "" {
+			continue
+		}
+
+		firstObservedAt := observedAt
+		if existing, ok := s.snapshots[listing.VIN]; ok {
+			firstObservedAt = existing.FirstObservedAt
+		}
+		s.snapshots[listing.VIN] = ListingSnapshot{
+			VIN:              listing.VIN,
+			Make:             listing.Make,
+			Model:            listing.Model,
+			Year:             listing.Year,
+			Trim:             listing.Trim,
+			VehicleCondition: listing.VehicleCondition,
+			Msrp:             listing.Msrp,
+			DealerCarfaxID:   listing.Dealer.CarfaxID,
+			DealerName:       listing.Dealer.Name,
+			FirstSeen:        listing.FirstSeen,
+			FirstObservedAt:  firstObservedAt,
+			LastObservedAt:   observedAt,
+		}
+		s.observations[listing.VIN] = append(s.observations[listing.VIN], ListingObservation{
+			CurrentPrice: listing.CurrentPrice,
+			ListPrice:    listing.ListPrice,
+			FollowCount:  listing.FollowCount,
+			ObservedAt:   observedAt,
+		})
+	}
+
+	return nil
+}
+
+// GetListingSnapshot implements ListingStore
+func (s *MemoryListingStore) GetListingSnapshot(ctx context.Context, vin string) (*ListingSnapshot, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot, ok := s.snapshots[vin]
+	if !ok {
+		return nil, ErrListingNotFound
+	}
+	return &snapshot, nil
+}
+
+// GetListingObservations implements ListingStore
+func (s *MemoryListingStore) GetListingObservations(ctx context.Context, vin string) ([]ListingObservation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]ListingObservation(nil), s.observations[vin]...), nil
+}
@@ -0,0 +1,262 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID is the (arbitrary but fixed) advisory lock key that serializes migration runners
+// Transaction-scoped locks are used so this also works behind Supabase's transaction pooler
+const migrationLockID int64 = 72720250001
+
+// migrationFilePattern matches files such as 0001_create_calls.up.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is a versioned schema change embedded in the binary
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationState reports whether a migration has been applied
+type MigrationState struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// LoadMigrations reads the embedded migrations, ordered by version
+func LoadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		contents, err := migrationFiles.ReadFile("migrations/" + entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(contents)
+		} else {
+			migration.Down = string(contents)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// MigrateUp applies every pending migration, each in its own transaction
+// Returns the migrations that were applied by this call
+func MigrateUp(ctx context.Context) ([]Migration, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, migration := range migrations {
+		ran, err := runMigration(ctx, migration, true)
+		if err != nil {
+			return applied, err
+		}
+		if ran {
+			slog.InfoContext(ctx, "applied migration", slog.Int64("version", migration.Version), slog.String("name", migration.Name))
+			applied = append(applied, migration)
+		}
+	}
+
+	return applied, nil
+}
+
+// MigrateDown rolls back the most recently applied migrations, newest first
+// Returns the migrations that were rolled back by this call
+func MigrateDown(ctx context.Context, steps int) ([]Migration, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	states, err := GetMigrationStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var rolledBack []Migration
+	for i := len(migrations) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+		if !states[i].Applied {
+			continue
+		}
+
+		ran, err := runMigration(ctx, migrations[i], false)
+		if err != nil {
+			return rolledBack, err
+		}
+		if ran {
+			slog.InfoContext(ctx, "rolled back migration", slog.Int64("version", migrations[i].Version), slog.String("name", migrations[i].Name))
+			rolledBack = append(rolledBack, migrations[i])
+		}
+	}
+
+	return rolledBack, nil
+}
+
+// GetMigrationStatus reports every embedded migration and whether it has been applied
+func GetMigrationStatus(ctx context.Context) ([]MigrationState, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]MigrationState, len(migrations))
+	for i, migration := range migrations {
+		states[i] = MigrationState{Version: migration.Version, Name: migration.Name}
+	}
+
+	// Nothing has been applied until the first runner creates the tracking table
+	var tracked bool
+	if err := Pool.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&tracked); err != nil {
+		return nil, fmt.Errorf("failed to check for schema_migrations: %w", err)
+	}
+	if !tracked {
+		return states, nil
+	}
+
+	rows, err := Pool.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	appliedAt := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		appliedAt[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, migration := range migrations {
+		if at, ok := appliedAt[migration.Version]; ok {
+			states[i].Applied = true
+			states[i].AppliedAt = &at
+		}
+	}
+
+	return states, nil
+}
+
+// execer is satisfied by both the pool and a transaction
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// ensureMigrationsTable creates the schema_migrations tracking table
+func ensureMigrationsTable(ctx context.Context, db execer) error {
+	_, err := db.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    bigint PRIMARY KEY,
+			name       text NOT NULL,
+			applied_at timestamptz NOT NULL DEFAULT now()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// runMigration applies (up) or reverts (down) one migration under the advisory lock
+// The applied state is re-checked inside the lock, so concurrent runners never apply a migration twice
+// Reports whether the migration was actually run
+func runMigration(ctx context.Context, migration Migration, up bool) (bool, error) {
+	tx, err := Pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLockID); err != nil {
+		return false, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+
+	if err := ensureMigrationsTable(ctx, tx); err != nil {
+		return false, err
+	}
+
+	var applied bool
+	err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, migration.Version).Scan(&applied)
+	if err != nil {
+		return false, fmt.Errorf("failed to check migration %d: %w", migration.Version, err)
+	}
+	if applied == up {
+		return false, nil
+	}
+
+	script, record := migration.Up, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`
+	if !up {
+		script, record = migration.Down, `DELETE FROM schema_migrations WHERE version = $1 AND name = $2`
+	}
+
+	if _, err := tx.Exec(ctx, script); err != nil {
+		return false, fmt.Errorf("migration %04d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+	if _, err := tx.Exec(ctx, record, migration.Version, migration.Name); err != nil {
+		return false, fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit migration %d: %w", migration.Version, err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,25 @@
+-- Calls placed by the agent on behalf of a user, one row per dealer.
+-- IF NOT EXISTS keeps this a no-op on databases created before migrations existed.
+CREATE TABLE IF NOT EXISTS calls (
+    id            bigint GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    user_id       text,
+    call_id       text,
+    model         text,
+    year          integer,
+    zipcode       text,
+    dealer_name   text,
+    phone_number  text,
+    msrp          bigint,
+    listing_price bigint,
+    status        text NOT NULL DEFAULT 'pending',
+    is_available  boolean,
+    deal_price    bigint,
+    remarks       text,
+    created_at    timestamptz NOT NULL DEFAULT now(),
+    updated_at    timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS calls_call_id_key ON calls (call_id);
+CREATE INDEX IF NOT EXISTS calls_user_id_idx ON calls (user_id);
+CREATE INDEX IF NOT EXISTS calls_status_created_at_idx ON calls (status, created_at DESC);
+CREATE INDEX IF NOT EXISTS calls_created_at_idx ON calls (created_at DESC);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS calls_best_deal_idx;
+
+ALTER TABLE calls
+    DROP COLUMN IF EXISTS vehicle_condition,
+    DROP COLUMN IF EXISTS make;
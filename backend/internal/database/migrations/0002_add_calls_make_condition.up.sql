@@ -0,0 +1,8 @@
+ALTER TABLE calls
+    ADD COLUMN IF NOT EXISTS make text,
+    ADD COLUMN IF NOT EXISTS vehicle_condition text;
+
+-- Supports GetBestDealForCar, which only looks at completed calls with a price.
+CREATE INDEX IF NOT EXISTS calls_best_deal_idx
+    ON calls (model, year, zipcode)
+    WHERE status = 'completed' AND is_available = true AND deal_price > 0;
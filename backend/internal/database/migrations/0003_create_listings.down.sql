@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS listing_observations;
+DROP TABLE IF EXISTS listings;
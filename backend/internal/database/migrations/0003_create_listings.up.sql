@@ -0,0 +1,28 @@
+-- Latest known state of every CARFAX listing we have fetched.
+CREATE TABLE IF NOT EXISTS listings (
+    vin               text PRIMARY KEY,
+    make              text NOT NULL,
+    model             text NOT NULL,
+    year              integer NOT NULL,
+    trim              text NOT NULL,
+    vehicle_condition text NOT NULL,
+    msrp              integer NOT NULL,
+    dealer_carfax_id  text NOT NULL,
+    dealer_name       text NOT NULL,
+    first_seen        text NOT NULL,
+    first_observed_at timestamptz NOT NULL,
+    last_observed_at  timestamptz NOT NULL
+);
+
+-- One row per fetch of a listing, for price history.
+CREATE TABLE IF NOT EXISTS listing_observations (
+    id            bigint GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    vin           text NOT NULL REFERENCES listings (vin) ON DELETE CASCADE,
+    current_price integer NOT NULL,
+    list_price    integer NOT NULL,
+    follow_count  integer NOT NULL,
+    observed_at   timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS listing_observations_vin_observed_at_idx
+    ON listing_observations (vin, observed_at);
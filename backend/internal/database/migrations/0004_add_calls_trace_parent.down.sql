@@ -0,0 +1,2 @@
+ALTER TABLE calls
+    DROP COLUMN IF EXISTS trace_parent;
@@ -0,0 +1,4 @@
+-- W3C traceparent of the span that dispatched the call, so the finish
+-- callback can be linked back to the original submit trace.
+ALTER TABLE calls
+    ADD COLUMN IF NOT EXISTS trace_parent text;
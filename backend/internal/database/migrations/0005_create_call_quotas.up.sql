@@ -0,0 +1,8 @@
+-- Calls each user has placed per UTC day, for daily dialing quotas.
+CREATE TABLE IF NOT EXISTS call_quotas (
+    user_id    text NOT NULL,
+    day        date NOT NULL,
+    calls      integer NOT NULL,
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, day)
+);
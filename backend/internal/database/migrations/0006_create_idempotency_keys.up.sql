@@ -0,0 +1,16 @@
+-- Idempotency-Key values sent with POST /api/calls/submit, with the hash of
+-- the request body and the response, so retried submissions are replayed
+-- instead of dialing again. response is NULL while the request is in flight.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id      text NOT NULL,
+    key          text NOT NULL,
+    request_hash text NOT NULL,
+    status_code  integer,
+    response     bytea,
+    created_at   timestamptz NOT NULL DEFAULT now(),
+    expires_at   timestamptz NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx
+    ON idempotency_keys (expires_at);
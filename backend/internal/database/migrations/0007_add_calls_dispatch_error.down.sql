@@ -0,0 +1,2 @@
+ALTER TABLE calls
+    DROP COLUMN IF EXISTS dispatch_error;
@@ -0,0 +1,3 @@
+-- Why the agent service did not accept a call, for calls in 'dispatch_failed'.
+ALTER TABLE calls
+    ADD COLUMN IF NOT EXISTS dispatch_error text;
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS call_status_transitions;
+
+ALTER TABLE calls DROP CONSTRAINT IF EXISTS calls_status_check;
+
+-- Undo the backfill in the up migration: before the state machine, 'pending'
+-- meant handed to the agent service and an unavailable car was 'failed'.
+-- Statuses that did not exist then are folded into those two, so this is lossy:
+-- applying the up migration again cannot tell which calls were only queued or
+-- which failures were no_answer, voicemail or cancelled.
+UPDATE calls SET status = 'pending' WHERE status IN ('dispatched', 'ringing', 'in_progress');
+UPDATE calls SET status = 'failed' WHERE status IN ('unavailable', 'no_answer', 'voicemail', 'cancelled');
@@ -0,0 +1,32 @@
+-- Calls created before the status state machine went to the agent service as
+-- soon as they were stored, so 'pending' meant dispatched, and an unavailable
+-- car was recorded as 'failed'.
+UPDATE calls SET status = 'dispatched' WHERE status = 'pending';
+UPDATE calls SET status = 'unavailable' WHERE status = 'failed' AND is_available = false;
+
+ALTER TABLE calls DROP CONSTRAINT IF EXISTS calls_status_check;
+ALTER TABLE calls
+    ADD CONSTRAINT calls_status_check CHECK (status IN (
+        'pending', 'dispatched', 'dispatch_failed', 'ringing', 'in_progress',
+        'completed', 'unavailable', 'no_answer', 'voicemail', 'failed', 'cancelled'
+    ));
+
+-- Every status change of a call; from_status is NULL for the row that created it.
+CREATE TABLE IF NOT EXISTS call_status_transitions (
+    id          bigint GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    call_id     text NOT NULL REFERENCES calls (call_id) ON DELETE CASCADE,
+    from_status text,
+    to_status   text NOT NULL,
+    reason      text,
+    created_at  timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS call_status_transitions_call_id_idx
+    ON call_status_transitions (call_id, id);
+
+-- Existing calls get one row for the status they are in, dated by their last update.
+INSERT INTO call_status_transitions (call_id, to_status, reason, created_at)
+SELECT call_id, status, 'backfilled', updated_at
+FROM calls
+WHERE call_id IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM call_status_transitions t WHERE t.call_id = calls.call_id);
@@ -0,0 +1,22 @@
+-- Outbound dispatch queue: one job per submission, holding the request
+-- posted to the agent service for its calls. Workers claim due jobs with
+-- FOR UPDATE SKIP LOCKED and hold them until locked_until; a job whose
+-- lease runs out is claimed again. Dead jobs are kept as the dead letters.
+CREATE TABLE IF NOT EXISTS call_jobs (
+    id           bigint GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    call_ids     text[] NOT NULL,
+    payload      jsonb NOT NULL,
+    trace_parent text,
+    status       text NOT NULL DEFAULT 'queued'
+        CHECK (status IN ('queued', 'running', 'succeeded', 'dead')),
+    attempts     integer NOT NULL DEFAULT 0,
+    run_at       timestamptz NOT NULL DEFAULT now(),
+    locked_until timestamptz,
+    last_error   text,
+    created_at   timestamptz NOT NULL DEFAULT now(),
+    updated_at   timestamptz NOT NULL DEFAULT now()
+);
+
+-- Only unfinished jobs are ever scanned by workers.
+CREATE INDEX IF NOT EXISTS call_jobs_due_idx
+    ON call_jobs (run_at, id) WHERE status IN ('queued', 'running');
@@ -0,0 +1,4 @@
+-- Response headers replayed with a stored response, such as the X-Quota-*
+-- headers, as a JSON object of header name to value.
+ALTER TABLE idempotency_keys
+    ADD COLUMN IF NOT EXISTS response_headers jsonb;
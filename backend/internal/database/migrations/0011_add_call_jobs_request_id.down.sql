@@ -0,0 +1,2 @@
+ALTER TABLE call_jobs
+    DROP COLUMN IF EXISTS request_id;
@@ -0,0 +1,4 @@
+-- X-Request-ID of the submission that queued the job, forwarded to the agent
+-- service with each dispatch attempt so its logs can be matched to the submit.
+ALTER TABLE call_jobs
+    ADD COLUMN IF NOT EXISTS request_id text;
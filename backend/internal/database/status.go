@@ -0,0 +1,134 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CallStatus is a step in a call's lifecycle
+type CallStatus string
+
+const (
+	// StatusPending is a call stored but not yet handed to the agent service
+	StatusPending CallStatus = "pending"
+	// StatusDispatched is a call the agent service accepted
+	StatusDispatched CallStatus = "dispatched"
+	// StatusDispatchFailed is a call the agent service did not accept
+	StatusDispatchFailed CallStatus = "dispatch_failed"
+	// StatusRinging is a call the agent service is dialing
+	StatusRinging CallStatus = "ringing"
+	// StatusInProgress is a call the dealer answered
+	StatusInProgress CallStatus = "in_progress"
+	// StatusCompleted is a call where the dealer had the car
+	StatusCompleted CallStatus = "completed"
+	// StatusUnavailable is a call where the dealer did not have the car
+	StatusUnavailable CallStatus = "unavailable"
+	// StatusNoAnswer is a call nobody picked up
+	StatusNoAnswer CallStatus = "no_answer"
+	// StatusVoicemail is a call that reached voicemail
+	StatusVoicemail CallStatus = "voicemail"
+	// StatusFailed is a call that could not be placed or broke off
+	StatusFailed CallStatus = "failed"
+	// StatusCancelled is a call withdrawn before it ended
+	StatusCancelled CallStatus = "cancelled"
+)
+
+// ErrInvalidTransition is returned when a call cannot move from its current status to the requested one
+var ErrInvalidTransition = errors.New("invalid call status transition")
+
+// callTransitions lists, for each status, the statuses a call may move to next
+// Statuses without an entry are terminal. A pending call may take a result directly, since the agent service
+// can finish a call before the dispatcher has recorded that the agent accepted it
+var callTransitions = map[CallStatus][]CallStatus{
+	StatusPending: {
+		StatusDispatched, StatusDispatchFailed, StatusCancelled,
+		StatusCompleted, StatusUnavailable, StatusNoAnswer, StatusVoicemail, StatusFailed,
+	},
+	StatusDispatched: {
+		StatusRinging, StatusInProgress,
+		StatusCompleted, StatusUnavailable, StatusNoAnswer, StatusVoicemail, StatusFailed, StatusCancelled,
+	},
+	StatusRinging:    {StatusInProgress, StatusNoAnswer, StatusVoicemail, StatusFailed, StatusCancelled},
+	StatusInProgress: {StatusCompleted, StatusUnavailable, StatusVoicemail, StatusFailed},
+}
+
+// CallStatuses lists every status in lifecycle order
+var CallStatuses = []CallStatus{
+	StatusPending, StatusDispatched, StatusDispatchFailed, StatusRinging, StatusInProgress,
+	StatusCompleted, StatusUnavailable, StatusNoAnswer, StatusVoicemail, StatusFailed, StatusCancelled,
+}
+
+// ParseCallStatus validates a status name
+func ParseCallStatus(value string) (CallStatus, error) {
+	for _, status := range CallStatuses {
+		if string(status) == value {
+			return status, nil
+		}
+	}
+	return "", fmt.Errorf("unknown call status %q", value)
+}
+
+// Terminal reports whether a call in this status can no longer change
+func (s CallStatus) Terminal() bool {
+	return len(callTransitions[s]) == 0
+}
+
+// IsResult reports whether the status is an outcome the agent service reports when a call ends
+func (s CallStatus) IsResult() bool {
+	switch s {
+	case StatusCompleted, StatusUnavailable, StatusNoAnswer, StatusVoicemail, StatusFailed:
+		return true
+	}
+	return false
+}
+
+// IsProgress reports whether the status is one the agent service reports while a call is under way
+func (s CallStatus) IsProgress() bool {
+	switch s {
+	case StatusRinging, StatusInProgress, StatusCancelled:
+		return true
+	}
+	return false
+}
+
+// CanTransition reports whether a call may move from one status to another
+func CanTransition(from, to CallStatus) bool {
+	for _, next := range callTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionError describes a status change rejected by the transition table
+type TransitionError struct {
+	CallID string
+	From   CallStatus
+	To     CallStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("call %s cannot move from %s to %s", e.CallID, e.From, e.To)
+}
+
+// Unwrap makes errors.Is(err, ErrInvalidTransition) hold
+func (e *TransitionError) Unwrap() error {
+	return ErrInvalidTransition
+}
+
+// checkTransition returns a TransitionError unless the call may move from one status to another
+func checkTransition(callID string, from, to CallStatus) error {
+	if !CanTransition(from, to) {
+		return &TransitionError{CallID: callID, From: from, To: to}
+	}
+	return nil
+}
+
+// ResultStatus maps a call outcome reported only by availability to its status
+func ResultStatus(isAvailable bool) CallStatus {
+	if isAvailable {
+		return StatusCompleted
+	}
+	return StatusUnavailable
+}
@@ -0,0 +1,33 @@
+package database
+
+import "testing"
+
+func TestCanTransition(t *testing.T) {
+	results := []CallStatus{StatusCompleted, StatusUnavailable, StatusNoAnswer, StatusVoicemail, StatusFailed}
+	allowed := map[CallStatus][]CallStatus{
+		// A result may arrive before the dispatch is recorded; progress reports may not
+		StatusPending:    append([]CallStatus{StatusDispatched, StatusDispatchFailed, StatusCancelled}, results...),
+		StatusDispatched: append([]CallStatus{StatusRinging, StatusInProgress, StatusCancelled}, results...),
+		StatusRinging:    {StatusInProgress, StatusNoAnswer, StatusVoicemail, StatusFailed, StatusCancelled},
+		StatusInProgress: {StatusCompleted, StatusUnavailable, StatusVoicemail, StatusFailed},
+	}
+
+	// Every pair of statuses is checked, so a move missing from allowed must be refused
+	for _, from := range CallStatuses {
+		want := make(map[CallStatus]bool)
+		for _, to := range allowed[from] {
+			want[to] = true
+		}
+		for _, to := range CallStatuses {
+			if got := CanTransition(from, to); got != want[to] {
+				t.Errorf("CanTransition(%s, %s) = %t, want %t", from, to, got, want[to])
+			}
+			if err := checkTransition("call-1", from, to); (err == nil) != want[to] {
+				t.Errorf("checkTransition(%s, %s) = %v, want allowed %t", from, to, err, want[to])
+			}
+		}
+		if got := from.Terminal(); got != (len(want) == 0) {
+			t.Errorf("%s.Terminal() = %t, want %t", from, got, len(want) == 0)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"hackutd2025/backend/internal/models"
+)
+
+var (
+	// ErrCallNotFound is returned when no call matches the given identifier
+	ErrCallNotFound = errors.New("call not found")
+	// ErrDuplicateCallID is returned when a call_id is already in use
+	ErrDuplicateCallID = errors.New("duplicate call_id")
+	// ErrJobLeaseLost is returned when a worker records an outcome for a job it no longer holds,
+	// because its lease ran out and another worker claimed the job again
+	ErrJobLeaseLost = errors.New("call job lease lost")
+	// ErrListingNotFound is returned when no listing has been stored for a VIN
+	ErrListingNotFound = errors.New("listing not found")
+)
+
+// NewCall holds the fields of a call when it is first created
+type NewCall struct {
+	UserID       string
+	CallID       string
+	Make         string
+	Model        string
+	Year         int
+	Condition    string
+	ZipCode      string
+	DealerName   string
+	PhoneNumber  string
+	MSRP         int64
+	ListingPrice int64
+	// TraceParent is the W3C traceparent of the span that dispatched the call, if any
+	TraceParent string
+}
+
+// CallResult is the outcome of a call as reported by the agent service
+type CallResult struct {
+	// Status must be a result status (see CallStatus.IsResult)
+	Status      CallStatus
+	IsAvailable bool
+	DealPrice   int
+	Remarks     string
+}
+
+// CallTransition is one recorded status change of a call
+type CallTransition struct {
+	// From is nil for the transition that created the call
+	From   *CallStatus `json:"from,omitempty"`
+	To     CallStatus  `json:"to"`
+	Reason *string     `json:"reason,omitempty"`
+	At     time.Time   `json:"at"`
+}
+
+// IdempotencyRecord is a stored Idempotency-Key and the outcome of the request that first used it
+type IdempotencyRecord struct {
+	// RequestHash identifies the request body the key was first used with
+	RequestHash string
+	// Completed is false while the first request is still being processed
+	Completed  bool
+	StatusCode int
+	// Headers holds the response headers that are replayed along with Response
+	Headers  map[string]string
+	Response []byte
+}
+
+// CallStore persists calls and their outcomes
+// Every method honors ctx cancellation and deadlines
+// Status changes follow the transition table in status.go; a disallowed change fails with a TransitionError
+// (matching ErrInvalidTransition) and leaves the calls unchanged. Every change is recorded with its time
+// PgCallStore is the production implementation; MemoryCallStore has the same semantics for tests and demos
+type CallStore interface {
+	// CreateCall inserts a new call in the 'pending' status; returns ErrDuplicateCallID if call_id is taken
+	CreateCall(ctx context.Context, call NewCall) error
+	// CreateCalls inserts new calls in the 'pending' status in one transaction: either all are stored or none are
+	CreateCalls(ctx context.Context, calls []NewCall) error
+	// TransitionCalls moves calls to a new status in one transaction: either all move or none do
+	// reason is recorded with each transition; returns ErrCallNotFound if any call_id is unknown
+	TransitionCalls(ctx context.Context, callIDs []string, to CallStatus, reason string) error
+	// UpdateCallResult records the outcome of a call and moves it to result.Status; returns ErrCallNotFound for an unknown call_id
+	UpdateCallResult(ctx context.Context, callID string, result CallResult) error
+	// EnqueueCalls inserts new calls in the 'pending' status and the job that dispatches them, in one transaction
+	// It returns the job ID; returns ErrDuplicateCallID if a call_id is taken
+	EnqueueCalls(ctx context.Context, calls []NewCall, job NewCallJob) (int64, error)
+	// ClaimCallJobs leases up to limit queued jobs that are due, and running jobs whose lease expired
+	// Each claim counts as an attempt; jobs held by another worker are skipped rather than waited for
+	ClaimCallJobs(ctx context.Context, limit int, lease time.Duration) ([]CallJob, error)
+	// RetryCallJob queues a claimed job again, to be claimed no earlier than runAt
+	// Like CompleteCallJob and FailCallJob, it returns ErrJobLeaseLost and changes nothing
+	// unless the job is still running on the attempt it was claimed for
+	RetryCallJob(ctx context.Context, job CallJob, runAt time.Time, lastError string) error
+	// CompleteCallJob marks a job succeeded and moves its calls that are still pending to 'dispatched'
+	CompleteCallJob(ctx context.Context, job CallJob) error
+	// FailCallJob marks a job dead and moves its calls that are still pending to 'dispatch_failed'
+	FailCallJob(ctx context.Context, job CallJob, lastError string) error
+	// GetCallTransitions returns a call's status changes, oldest first; returns ErrCallNotFound for an unknown call_id
+	GetCallTransitions(ctx context.Context, callID string) ([]CallTransition, error)
+	// GetCallTraceParent returns the traceparent stored with a call ("" if none); returns ErrCallNotFound for an unknown call_id
+	GetCallTraceParent(ctx context.Context, callID string) (string, error)
+	// GetCallByUserID returns the user's most recent call; returns ErrCallNotFound if there is none
+	GetCallByUserID(ctx context.Context, userID string) (*Call, error)
+	// GetAllCalls returns every call, newest first
+	GetAllCalls(ctx context.Context) ([]Call, error)
+	// GetCallsByStatus returns the calls in a status, newest first
+	GetCallsByStatus(ctx context.Context, status CallStatus) ([]Call, error)
+	// GetCallsForUser returns a user's calls, newest first, optionally limited to one status ("" for all)
+	GetCallsForUser(ctx context.Context, userID string, status CallStatus) ([]Call, error)
+	// CountCallsByStatus returns how many calls are in each status
+	CountCallsByStatus(ctx context.Context) (map[string]int64, error)
+	// ConsumeDailyQuota adds n calls to the user's count for the UTC day of day, unless that would exceed limit
+	// It returns the count after the call and whether the calls were allowed; a refused call leaves the count unchanged
+	ConsumeDailyQuota(ctx context.Context, userID string, day time.Time, n, limit int) (int, bool, error)
+	// RefundDailyQuota takes n calls off the user's count for the UTC day of day, never going below zero
+	RefundDailyQuota(ctx context.Context, userID string, day time.Time, n int) error
+	// ReserveIdempotencyKey claims a user's key for a request until lockUntil, returning nil if it was free
+	// If the key is already held and not expired, the existing record is returned and nothing changes
+	ReserveIdempotencyKey(ctx context.Context, userID, key, requestHash string, lockUntil time.Time) (*IdempotencyRecord, error)
+	// CompleteIdempotencyKey stores the response and its replayed headers for a reserved key and keeps them until expiresAt
+	CompleteIdempotencyKey(ctx context.Context, userID, key string, statusCode int, headers map[string]string, response []byte, expiresAt time.Time) error
+	// ReleaseIdempotencyKey drops a reserved key so the request can be retried with it
+	ReleaseIdempotencyKey(ctx context.Context, userID, key string) error
+	// DeleteExpiredIdempotencyKeys drops every expired key and returns how many there were
+	// Expired keys are already free to reserve; this only reclaims their storage
+	DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error)
+	// GetBestDealForCar returns the lowest completed deal price for a car, and whether one exists
+	GetBestDealForCar(ctx context.Context, vehicleMake, model string, year int, condition, zipcode string) (int64, bool, error)
+}
+
+// ListingStore persists listing snapshots and their price history
+// PgListingStore is the production implementation; MemoryListingStore has the same semantics for tests and demos
+type ListingStore interface {
+	// UpsertListings stores the latest snapshot of each listing and records an observation of its prices
+	// Listings without a VIN are skipped; listings are written in VIN order
+	UpsertListings(ctx context.Context, listings []models.Listing) error
+	// GetListingSnapshot returns the stored snapshot for a VIN; returns ErrListingNotFound if there is none
+	GetListingSnapshot(ctx context.Context, vin string) (*ListingSnapshot, error)
+	// GetListingObservations returns the price observations for a VIN, oldest first
+	GetListingObservations(ctx context.Context, vin string) ([]ListingObservation, error)
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"hackutd2025/backend/internal/logging"
+)
+
+// IdempotencySweeper deletes expired idempotency keys in the background
+// Reserving a key never waits on this: an expired key is taken over in place, the sweep only reclaims storage
+type IdempotencySweeper struct {
+	store    CallStore
+	interval time.Duration
+	wg       sync.WaitGroup
+}
+
+// NewIdempotencySweeper creates a sweeper that deletes the expired keys in store every interval
+func NewIdempotencySweeper(store CallStore, interval time.Duration) *IdempotencySweeper {
+	return &IdempotencySweeper{store: store, interval: interval}
+}
+
+// Start sweeps every interval until ctx ends
+func (s *IdempotencySweeper) Start(ctx context.Context) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.sweep(ctx)
+			}
+		}
+	}()
+}
+
+// Wait blocks until the sweeper has stopped
+func (s *IdempotencySweeper) Wait() {
+	s.wg.Wait()
+}
+
+// sweep deletes the keys that have expired so far
+func (s *IdempotencySweeper) sweep(ctx context.Context) {
+	deleted, err := s.store.DeleteExpiredIdempotencyKeys(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.ErrorContext(ctx, "failed to delete expired idempotency keys", logging.Err(err))
+		}
+		return
+	}
+	if deleted > 0 {
+		slog.DebugContext(ctx, "deleted expired idempotency keys", slog.Int64("deleted", deleted))
+	}
+}
@@ -0,0 +1,110 @@
+package dispatch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"hackutd2025/backend/internal/logging"
+	"hackutd2025/backend/internal/metrics"
+	"hackutd2025/backend/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// IdempotencyKeyHeader carries the key the agent service uses to recognize a batch it was already sent
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxErrorBodyBytes bounds how much of an agent error response is read
+const maxErrorBodyBytes = 4 << 10
+
+// ErrRejected is returned when the agent service refuses a batch in a way that retrying cannot fix
+var ErrRejected = errors.New("agent service rejected calls")
+
+// Client sends call batches to the agent service
+type Client struct {
+	url    string
+	client *http.Client
+}
+
+// NewClient creates a client for the agent service at baseURL, posting to baseURL + /calls/init
+// Each request is bounded by timeout
+func NewClient(baseURL string, timeout time.Duration) *Client {
+	return &Client{
+		url: strings.TrimRight(baseURL, "/") + "/calls/init",
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: logging.NewTransport(metrics.NewTransport("agent", tracing.NewTransport(nil))),
+		},
+	}
+}
+
+// Dispatch posts a JSON array of calls to the agent service and returns its decoded response
+// Every attempt at the same batch must send the same key, so the agent dials a batch it already accepted only once
+// Errors wrapping ErrRejected are permanent; any other error may succeed on retry
+func (c *Client) Dispatch(ctx context.Context, key string, payload []byte, calls int) (result interface{}, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "dispatch calls", trace.WithAttributes(attribute.Int("calls", calls)))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	// The payload carries dealer phone numbers, so only its shape is logged
+	slog.InfoContext(ctx, "calling agent service", slog.String("url", c.url), slog.Int("calls", calls))
+
+	// Create request
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Set headers
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdempotencyKeyHeader, key)
+
+	// Execute request
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check status code
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		errorBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		err := fmt.Errorf("agent service returned status %d: %s", resp.StatusCode, errorBody)
+		// Other client errors mean the batch itself is unacceptable
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+			resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+			err = fmt.Errorf("%w: %w", ErrRejected, err)
+		}
+		return nil, err
+	}
+
+	// Parse response
+	var agentResponse interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&agentResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse agent response: %w", err)
+	}
+
+	// The agent service answers 200 with {"status": "error"} when the calling provider fails the batch,
+	// which is often transient, so it is retried
+	if body, ok := agentResponse.(map[string]interface{}); ok && body["status"] == "error" {
+		return nil, fmt.Errorf("agent service reported an error: %v", body["error"])
+	}
+
+	slog.InfoContext(ctx, "initiated calls with agent service", slog.Int("calls", calls))
+	return agentResponse, nil
+}
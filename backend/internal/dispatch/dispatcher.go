@@ -0,0 +1,276 @@
+package dispatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"hackutd2025/backend/internal/database"
+	"hackutd2025/backend/internal/logging"
+	"hackutd2025/backend/internal/metrics"
+	"hackutd2025/backend/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Outcomes of a job attempt, as counted in metrics
+const (
+	OutcomeSucceeded = "succeeded"
+	OutcomeRetried   = "retried"
+	OutcomeDead      = "dead"
+)
+
+// maxErrorLength bounds the agent error stored with a job and its calls
+const maxErrorLength = 1000
+
+// completeAttempts is how many times a job the agent service accepted is recorded before it is left to its lease
+const completeAttempts = 3
+
+// JobStore persists the dispatch queue
+type JobStore interface {
+	ClaimCallJobs(ctx context.Context, limit int, lease time.Duration) ([]database.CallJob, error)
+	RetryCallJob(ctx context.Context, job database.CallJob, runAt time.Time, lastError string) error
+	CompleteCallJob(ctx context.Context, job database.CallJob) error
+	FailCallJob(ctx context.Context, job database.CallJob, lastError string) error
+}
+
+// Options configures a Dispatcher
+type Options struct {
+	// Workers bounds how many jobs are dispatched at once
+	Workers int
+	// PollInterval is how often the queue is checked while no job is announced
+	PollInterval time.Duration
+	// Lease is how long a claimed job is held; it must outlast the client's timeout
+	Lease time.Duration
+	// MaxAttempts is how many times a job is tried before it is dead-lettered
+	MaxAttempts int
+	// RetryBaseDelay is the delay after the first failed attempt; it doubles with each attempt up to RetryMaxDelay
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+}
+
+// Dispatcher sends queued call jobs to the agent service from a bounded pool of workers
+// Several dispatchers, in one process or many, can share a queue: each job is claimed by one of them at a time
+type Dispatcher struct {
+	store   JobStore
+	client  *Client
+	options Options
+	now     func() time.Time
+
+	// wake is signalled when jobs are queued or a worker frees up
+	wake chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates a dispatcher taking jobs from store and sending them with client
+func New(store JobStore, client *Client, options Options) *Dispatcher {
+	return &Dispatcher{
+		store:   store,
+		client:  client,
+		options: options,
+		now:     time.Now,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Start claims and dispatches jobs until ctx ends
+// Jobs already being dispatched are then finished rather than abandoned mid-request; Wait waits for them
+func (d *Dispatcher) Start(ctx context.Context) {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		d.run(ctx)
+	}()
+}
+
+// Notify wakes the dispatcher to claim new jobs without waiting for the next poll
+func (d *Dispatcher) Notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Wait blocks until the dispatcher has stopped and every claimed job has an outcome
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// run is the claim loop; it only claims as many jobs as there are idle workers
+func (d *Dispatcher) run(ctx context.Context) {
+	ticker := time.NewTicker(d.options.PollInterval)
+	defer ticker.Stop()
+
+	slots := make(chan struct{}, d.options.Workers)
+	for ctx.Err() == nil {
+		free := d.options.Workers - len(slots)
+		if free > 0 {
+			jobs, err := d.store.ClaimCallJobs(ctx, free, d.options.Lease)
+			if err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "failed to claim call jobs", logging.Err(err))
+			}
+
+			for _, job := range jobs {
+				slots <- struct{}{}
+				d.wg.Add(1)
+				go func() {
+					defer d.wg.Done()
+					defer func() {
+						<-slots
+						d.Notify()
+					}()
+					// An attempt outlives shutdown, so a call is never cut off after the agent may have dialed it
+					d.process(context.WithoutCancel(ctx), job)
+				}()
+			}
+
+			// A full claim suggests more jobs are due
+			if err == nil && len(jobs) == free {
+				continue
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// process makes one attempt at a job and records its outcome
+func (d *Dispatcher) process(ctx context.Context, job database.CallJob) {
+	// Carry the submission's request ID, so the attempt is logged under it and the agent service receives it
+	if job.RequestID != nil {
+		ctx = logging.WithRequestID(ctx, *job.RequestID)
+	}
+
+	ctx, span := tracing.Tracer().Start(ctx, "dispatch job", trace.WithAttributes(
+		attribute.Int64("job.id", job.ID),
+		attribute.Int("job.attempt", job.Attempts),
+	))
+	defer span.End()
+
+	// Link the attempt to the submission that queued it
+	if job.TraceParent != nil {
+		tracing.LinkTraceParent(ctx, *job.TraceParent, attribute.Int64("job.id", job.ID))
+	}
+
+	attrs := []any{slog.Int64("job_id", job.ID), slog.Int("attempt", job.Attempts), slog.Int("calls", len(job.CallIDs))}
+
+	// The lease of the last allowed attempt ran out without an outcome, so the agent may or may not have the calls
+	if job.Attempts > d.options.MaxAttempts {
+		d.fail(ctx, job, "no outcome recorded for the final attempt", attrs)
+		return
+	}
+
+	_, err := d.client.Dispatch(ctx, jobKey(job), job.Payload, len(job.CallIDs))
+	switch {
+	case err == nil:
+		if err := d.complete(ctx, job); err != nil {
+			if errors.Is(err, database.ErrJobLeaseLost) {
+				leaseLost(ctx, attrs)
+				return
+			}
+			// The job is claimed again once its lease runs out; the agent service recognizes the resent batch by its key
+			slog.ErrorContext(ctx, "failed to record dispatched call job", append(attrs, logging.Err(err))...)
+			return
+		}
+		metrics.CallJob(OutcomeSucceeded)
+		slog.InfoContext(ctx, "call job dispatched", attrs...)
+
+	case errors.Is(err, ErrRejected) || job.Attempts >= d.options.MaxAttempts:
+		d.fail(ctx, job, err.Error(), attrs)
+
+	default:
+		delay := d.backoff(job.Attempts)
+		if err := d.store.RetryCallJob(ctx, job, d.now().Add(delay), truncate(err.Error(), maxErrorLength)); err != nil {
+			if errors.Is(err, database.ErrJobLeaseLost) {
+				leaseLost(ctx, attrs)
+				return
+			}
+			slog.ErrorContext(ctx, "failed to requeue call job", append(attrs, logging.Err(err))...)
+			return
+		}
+		metrics.CallJob(OutcomeRetried)
+		slog.WarnContext(ctx, "call job failed; will retry", append(attrs, slog.Duration("retry_in", delay), logging.Err(err))...)
+	}
+}
+
+// complete records that the agent service accepted a job, retrying apart from the dispatch attempt
+// so that a brief database failure does not leave the batch to be sent again
+func (d *Dispatcher) complete(ctx context.Context, job database.CallJob) error {
+	var err error
+	for attempt := 1; attempt <= completeAttempts; attempt++ {
+		// Another worker holds the job now, so retrying cannot succeed
+		if err = d.store.CompleteCallJob(ctx, job); err == nil || errors.Is(err, database.ErrJobLeaseLost) {
+			return err
+		}
+		if attempt == completeAttempts {
+			break
+		}
+
+		timer := time.NewTimer(d.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+	return err
+}
+
+// jobKey is the idempotency key sent with every attempt at a job
+func jobKey(job database.CallJob) string {
+	return fmt.Sprintf("call-job-%d", job.ID)
+}
+
+// fail dead-letters a job, moving its calls to dispatch_failed
+func (d *Dispatcher) fail(ctx context.Context, job database.CallJob, reason string, attrs []any) {
+	if err := d.store.FailCallJob(ctx, job, truncate(reason, maxErrorLength)); err != nil {
+		if errors.Is(err, database.ErrJobLeaseLost) {
+			leaseLost(ctx, attrs)
+			return
+		}
+		slog.ErrorContext(ctx, "failed to dead-letter call job", append(attrs, logging.Err(err))...)
+		return
+	}
+	metrics.CallJob(OutcomeDead)
+	slog.ErrorContext(ctx, "call job dead-lettered", append(attrs, slog.String("reason", reason))...)
+}
+
+// leaseLost logs an attempt whose outcome was not recorded because another worker claimed the job since
+// The job is dropped: its current holder records the outcome of its own attempt
+func leaseLost(ctx context.Context, attrs []any) {
+	slog.WarnContext(ctx, "call job was claimed by another worker; dropping this attempt", attrs...)
+}
+
+// backoff returns the delay after a job's attempt'th failure: the base delay doubled per earlier attempt, capped,
+// with the upper half randomized so jobs that failed together do not all retry together
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.options.RetryMaxDelay
+	if attempt <= 32 {
+		if exponential := d.options.RetryBaseDelay << (attempt - 1); exponential > 0 && exponential < delay {
+			delay = exponential
+		}
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 character
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,240 @@
+package dispatch
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"hackutd2025/backend/internal/database"
+	"hackutd2025/backend/internal/logging"
+)
+
+// agentRequest is what the stand-in agent service received
+type agentRequest struct {
+	key       string
+	requestID string
+}
+
+// fakeAgent records the batches posted to /calls/init and answers with status and body
+type fakeAgent struct {
+	mu       sync.Mutex
+	requests []agentRequest
+	status   int
+	body     string
+}
+
+func newFakeAgent(t *testing.T, status int, body string) (*fakeAgent, *Client) {
+	t.Helper()
+	agent := &fakeAgent{status: status, body: body}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agent.mu.Lock()
+		agent.requests = append(agent.requests, agentRequest{
+			key:       r.Header.Get(IdempotencyKeyHeader),
+			requestID: r.Header.Get(logging.RequestIDHeader),
+		})
+		agent.mu.Unlock()
+		w.WriteHeader(agent.status)
+		io.WriteString(w, agent.body)
+	}))
+	t.Cleanup(server.Close)
+	return agent, NewClient(server.URL, 5*time.Second)
+}
+
+// flakyCompleteStore fails to record the first failures completions, as a database hiccup would
+type flakyCompleteStore struct {
+	*database.MemoryCallStore
+	failures int
+}
+
+func (s *flakyCompleteStore) CompleteCallJob(ctx context.Context, job database.CallJob) error {
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("connection reset")
+	}
+	return s.MemoryCallStore.CompleteCallJob(ctx, job)
+}
+
+var testOptions = Options{
+	Workers:        1,
+	PollInterval:   time.Second,
+	Lease:          time.Minute,
+	MaxAttempts:    3,
+	RetryBaseDelay: time.Millisecond,
+	RetryMaxDelay:  10 * time.Millisecond,
+}
+
+// enqueue queues one call on store and claims its job
+func enqueue(t *testing.T, store *database.MemoryCallStore, requestID string) database.CallJob {
+	t.Helper()
+	queue(t, store, requestID)
+	return claim(t, store, time.Minute)
+}
+
+// queue queues one call on store without claiming its job
+func queue(t *testing.T, store *database.MemoryCallStore, requestID string) {
+	t.Helper()
+	_, err := store.EnqueueCalls(context.Background(), []database.NewCall{{UserID: "user-1", CallID: "call-1"}}, database.NewCallJob{
+		Payload:   []byte(`[{"user_id":"call-1"}]`),
+		RequestID: requestID,
+	})
+	if err != nil {
+		t.Fatalf("EnqueueCalls: %v", err)
+	}
+}
+
+// claim claims the one due job on store for lease; a zero lease lets the job be claimed again at once
+func claim(t *testing.T, store *database.MemoryCallStore, lease time.Duration) database.CallJob {
+	t.Helper()
+	jobs, err := store.ClaimCallJobs(context.Background(), 1, lease)
+	if err != nil || len(jobs) != 1 {
+		t.Fatalf("ClaimCallJobs = %v, %v; want one job", jobs, err)
+	}
+	return jobs[0]
+}
+
+// callStatus returns the stored status of call-1
+func callStatus(t *testing.T, store *database.MemoryCallStore) database.CallStatus {
+	t.Helper()
+	calls, err := store.GetAllCalls(context.Background())
+	if err != nil || len(calls) != 1 {
+		t.Fatalf("GetAllCalls = %v, %v; want one call", calls, err)
+	}
+	return *calls[0].Status
+}
+
+func TestProcessSendsJobKeyAndRequestID(t *testing.T) {
+	agent, client := newFakeAgent(t, http.StatusOK, `{"status": "success"}`)
+	store := database.NewMemoryCallStore()
+	d := New(store, client, testOptions)
+
+	job := enqueue(t, store, "req-1")
+	d.process(context.Background(), job)
+
+	if len(agent.requests) != 1 {
+		t.Fatalf("agent received %d requests, want 1", len(agent.requests))
+	}
+	if got := agent.requests[0]; got.key != "call-job-1" || got.requestID != "req-1" {
+		t.Errorf("agent request = %+v, want key call-job-1 and request ID req-1", got)
+	}
+	if status := callStatus(t, store); status != database.StatusDispatched {
+		t.Errorf("status = %s, want dispatched", status)
+	}
+}
+
+func TestProcessRetriesCompletionWithoutResending(t *testing.T) {
+	agent, client := newFakeAgent(t, http.StatusOK, `{"status": "success"}`)
+	store := &flakyCompleteStore{MemoryCallStore: database.NewMemoryCallStore(), failures: completeAttempts - 1}
+	d := New(store, client, testOptions)
+
+	d.process(context.Background(), enqueue(t, store.MemoryCallStore, ""))
+
+	if len(agent.requests) != 1 {
+		t.Fatalf("agent received %d requests, want 1", len(agent.requests))
+	}
+	if status := callStatus(t, store.MemoryCallStore); status != database.StatusDispatched {
+		t.Errorf("status = %s, want dispatched", status)
+	}
+}
+
+func TestProcessResendsSameKeyAfterLostCompletion(t *testing.T) {
+	agent, client := newFakeAgent(t, http.StatusOK, `{"status": "success"}`)
+	store := &flakyCompleteStore{MemoryCallStore: database.NewMemoryCallStore(), failures: completeAttempts}
+	d := New(store, client, testOptions)
+
+	// The lease runs out without the completion recorded and the job is claimed again
+	queue(t, store.MemoryCallStore, "")
+	d.process(context.Background(), claim(t, store.MemoryCallStore, 0))
+	if status := callStatus(t, store.MemoryCallStore); status != database.StatusPending {
+		t.Fatalf("status = %s, want pending while the completion is lost", status)
+	}
+	d.process(context.Background(), claim(t, store.MemoryCallStore, time.Minute))
+
+	if len(agent.requests) != 2 || agent.requests[0].key != agent.requests[1].key {
+		t.Fatalf("agent requests = %+v, want two with the same key", agent.requests)
+	}
+	if status := callStatus(t, store.MemoryCallStore); status != database.StatusDispatched {
+		t.Errorf("status = %s, want dispatched", status)
+	}
+}
+
+func TestProcessDropsJobClaimedByAnotherWorker(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{"accepted", http.StatusOK, `{"status": "success"}`},
+		{"retryable", http.StatusServiceUnavailable, "overloaded"},
+		{"rejected", http.StatusBadRequest, "bad batch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, client := newFakeAgent(t, tt.status, tt.body)
+			store := database.NewMemoryCallStore()
+			d := New(store, client, testOptions)
+			ctx := context.Background()
+
+			// The first worker's lease runs out mid-request and a second worker claims the job
+			queue(t, store, "")
+			stale := claim(t, store, 0)
+			current := claim(t, store, time.Minute)
+
+			// The stale worker finishes last; its outcome must not touch the job or its calls
+			d.process(ctx, stale)
+			if status := callStatus(t, store); status != database.StatusPending {
+				t.Fatalf("status after the stale attempt = %s, want pending", status)
+			}
+			if err := store.RetryCallJob(ctx, stale, time.Now(), "stale"); !errors.Is(err, database.ErrJobLeaseLost) {
+				t.Errorf("RetryCallJob with the stale claim = %v, want %v", err, database.ErrJobLeaseLost)
+			}
+
+			// The current holder still records its own outcome
+			if err := store.CompleteCallJob(ctx, current); err != nil {
+				t.Fatalf("CompleteCallJob with the current claim: %v", err)
+			}
+			if status := callStatus(t, store); status != database.StatusDispatched {
+				t.Errorf("status = %s, want dispatched", status)
+			}
+		})
+	}
+}
+
+func TestCompleteStopsWhenContextEnds(t *testing.T) {
+	store := &flakyCompleteStore{MemoryCallStore: database.NewMemoryCallStore(), failures: completeAttempts}
+	options := testOptions
+	options.RetryBaseDelay, options.RetryMaxDelay = time.Hour, time.Hour
+	d := New(store, nil, options)
+	job := enqueue(t, store.MemoryCallStore, "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	done := make(chan error, 1)
+	go func() { done <- d.complete(ctx, job) }()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("complete = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("complete kept waiting to retry after its context ended")
+	}
+}
+
+func TestDispatchBoundsErrorBody(t *testing.T) {
+	_, client := newFakeAgent(t, http.StatusBadGateway, strings.Repeat("x", 1<<20))
+
+	_, err := client.Dispatch(context.Background(), "call-job-1", []byte(`[]`), 0)
+	if err == nil {
+		t.Fatal("Dispatch succeeded, want an error")
+	}
+	if len(err.Error()) > maxErrorBodyBytes+100 {
+		t.Errorf("error is %d bytes, want at most about %d", len(err.Error()), maxErrorBodyBytes)
+	}
+}
@@ -1,24 +1,38 @@
 package handlers
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"hackutd2025/backend/internal/auth"
 	"hackutd2025/backend/internal/database"
+	"hackutd2025/backend/internal/dispatch"
+	"hackutd2025/backend/internal/listings"
+	"hackutd2025/backend/internal/logging"
+	"hackutd2025/backend/internal/metrics"
+	"hackutd2025/backend/internal/ratelimit"
+	"hackutd2025/backend/internal/tracing"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // CallSubmitRequest represents the request from frontend for a single call
 type CallSubmitRequest struct {
 	UserID       string `json:"user_id"`
+	Make         string `json:"make"`
 	Model        string `json:"model"`
 	Year         int    `json:"year"`
+	Condition    string `json:"condition"`
 	ZipCode      string `json:"zipcode"`
 	DealerName   string `json:"dealer_name"`
 	PhoneNumber  string `json:"phone_number"`
@@ -32,6 +46,7 @@ type AgentCallRequest struct {
 	Make           string `json:"make"`
 	Model          string `json:"model"`
 	Year           string `json:"year"`
+	Condition      string `json:"condition"`
 	ZipCode        string `json:"zipcode"`
 	DealerName     string `json:"dealer_name"`
 	PhoneNumber    string `json:"phone_number"`
@@ -41,22 +56,89 @@ type AgentCallRequest struct {
 	CompetingPrice int    `json:"competing_price"`
 }
 
-// CallSubmitResponse represents the response from the agent service
+// CallHandler serves the call endpoints on top of a CallStore
+type CallHandler struct {
+	store      database.CallStore
+	dispatcher *dispatch.Dispatcher
+	webhook    *auth.WebhookVerifier
+	limiter    *ratelimit.Limiter
+
+	idempotencyTTL  time.Duration
+	idempotencyLock time.Duration
+}
+
+// CallHandlerOptions configures a CallHandler
+type CallHandlerOptions struct {
+	// Dispatcher, when not nil, is woken as soon as calls are queued instead of at its next poll
+	Dispatcher *dispatch.Dispatcher
+	// Webhook, when not nil, verifies the signature of agent callbacks
+	Webhook *auth.WebhookVerifier
+	// Limiter, when not nil, bounds how many calls each user can submit
+	Limiter *ratelimit.Limiter
+	// IdempotencyTTL is how long a submission's response is replayed for its Idempotency-Key; 0 ignores the header
+	IdempotencyTTL time.Duration
+	// IdempotencyLock is how long an in-flight submission holds its key; it must outlast the request
+	IdempotencyLock time.Duration
+}
+
+// anonymousUser is the rate limit key for submissions without an authenticated user
+const anonymousUser = "anonymous"
+
+// maxFinishBodyBytes bounds the agent callback body read before its signature is checked
+const maxFinishBodyBytes = 1 << 20
+
+// NewCallHandler creates the call handlers over store, queueing submitted calls for the dispatcher
+func NewCallHandler(store database.CallStore, options CallHandlerOptions) *CallHandler {
+	return &CallHandler{
+		store:      store,
+		dispatcher: options.Dispatcher,
+		webhook:    options.Webhook,
+		limiter:    options.Limiter,
+
+		idempotencyTTL:  options.IdempotencyTTL,
+		idempotencyLock: options.IdempotencyLock,
+	}
+}
+
+// CallSubmitResponse represents the response to a call submission
 type CallSubmitResponse struct {
 	Success bool        `json:"success"`
 	Message string      `json:"message"`
 	Data    interface{} `json:"data,omitempty"`
+	// CallIDs are the IDs generated for the submitted calls, in request order
+	CallIDs []string `json:"call_ids,omitempty"`
+	// Calls reports the outcome of each submitted call, in request order
+	Calls []CallOutcome `json:"calls,omitempty"`
+}
+
+// CallOutcome reports what happened to one submitted call
+type CallOutcome struct {
+	// Index is the call's position in the submitted array
+	Index  int    `json:"index"`
+	CallID string `json:"call_id"`
+	// Status is the call's status after submission, pending until a worker hands it to the agent service
+	Status database.CallStatus `json:"status"`
 }
 
 // SubmitCalls handles POST /api/calls/submit
-// Receives call requests from frontend and forwards them to the agent service
-func SubmitCalls(w http.ResponseWriter, r *http.Request) {
+// Receives call requests from frontend and queues them for the agent service, answering 202 with their IDs
+// With an Idempotency-Key header, retries of the same submission are answered without dialing again
+func (h *CallHandler) SubmitCalls(w http.ResponseWriter, r *http.Request) {
+	if key := r.Header.Get(IdempotencyKeyHeader); key != "" && h.idempotencyTTL > 0 {
+		h.submitIdempotent(w, r, key)
+		return
+	}
+	h.submitCalls(w, r)
+}
+
+// submitCalls creates the submitted calls together with the job that dispatches them
+func (h *CallHandler) submitCalls(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	// Parse request body
 	var requests []CallSubmitRequest
 	if err := json.NewDecoder(r.Body).Decode(&requests); err != nil {
-		log.Printf("Error decoding request: %v", err)
+		slog.WarnContext(r.Context(), "invalid call submit body", logging.Err(err))
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(CallSubmitResponse{
 			Success: false,
@@ -85,33 +167,90 @@ func SubmitCalls(w http.ResponseWriter, r *http.Request) {
 			})
 			return
 		}
+
+		condition, err := listings.ParseCondition(req.Condition)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(CallSubmitResponse{
+				Success: false,
+				Message: fmt.Sprintf("Request %d: %v", i, err),
+			})
+			return
+		}
+
+		// Calls always belong to the authenticated caller, whatever the body says
+		if userID, ok := auth.UserID(r.Context()); ok {
+			requests[i].UserID = userID
+		}
+
+		// Older clients only ever searched for new Toyotas
+		if req.Make == "" {
+			requests[i].Make = listings.DefaultMake
+		}
+		requests[i].Condition = string(condition)
 	}
 
-	log.Printf("Received %d call request(s)", len(requests))
+	slog.InfoContext(r.Context(), "received call requests", slog.Int("count", len(requests)))
+
+	// Every entry dials a dealership, so the whole batch must fit the caller's limits before any call is made
+	// What was taken is refunded if the calls are not queued after all
+	var quota ratelimit.Decision
+	if h.limiter != nil {
+		var ok bool
+		if quota, ok = h.allowCalls(w, r, len(requests)); !ok {
+			return
+		}
+	}
 
 	// Transform requests and add generated fields
 	agentRequests := make([]AgentCallRequest, len(requests))
+	newCalls := make([]database.NewCall, len(requests))
+	callIDs := make([]string, len(requests))
 	for i, req := range requests {
+		// Stop before doing more work if the client went away or the request budget ran out
+		if err := r.Context().Err(); err != nil {
+			slog.WarnContext(r.Context(), "call submission aborted",
+				slog.Int("prepared", i), slog.Int("requested", len(requests)), logging.Err(err))
+			h.refundCalls(w, r, quota)
+			w.WriteHeader(http.StatusGatewayTimeout)
+			json.NewEncoder(w).Encode(CallSubmitResponse{
+				Success: false,
+				Message: fmt.Sprintf("Request cancelled: %v", err),
+			})
+			return
+		}
+
 		callID := generateUserID()
+		callIDs[i] = callID
+
+		// One span per call; its traceparent is stored so the finish callback can link back to it
+		ctx, span := tracing.Tracer().Start(r.Context(), "prepare call", trace.WithAttributes(
+			attribute.String("call.id", callID),
+			attribute.String("call.model", req.Model),
+			attribute.Int("call.year", req.Year),
+		))
 
 		// Check for existing deals for the same car
-		bestPrice, hasExistingDeal, err := database.GetBestDealForCar(req.Model, req.Year, req.ZipCode)
+		bestPrice, hasExistingDeal, err := h.store.GetBestDealForCar(ctx, req.Make, req.Model, req.Year, req.Condition, req.ZipCode)
 		isDealing := false
 		competingPrice := 0
 
 		if err != nil {
-			log.Printf("⚠️  Warning: Failed to check for existing deals: %v", err)
+			slog.WarnContext(r.Context(), "failed to check for existing deals", logging.Err(err))
 		} else if hasExistingDeal {
 			isDealing = true
 			competingPrice = int(bestPrice)
-			log.Printf("💰 Found existing deal for %s %d in %s: $%d", req.Model, req.Year, req.ZipCode, competingPrice)
+			slog.InfoContext(r.Context(), "found existing deal",
+				slog.String("condition", req.Condition), slog.String("make", req.Make), slog.String("model", req.Model),
+				slog.Int("year", req.Year), slog.String("zip", req.ZipCode), slog.Int("competing_price", competingPrice))
 		}
 
 		agentRequests[i] = AgentCallRequest{
 			CallID:         callID,
-			Make:           "toyota", // Constant as specified
+			Make:           strings.ToLower(req.Make),
 			Model:          req.Model,
 			Year:           strconv.Itoa(req.Year),
+			Condition:      req.Condition,
 			ZipCode:        req.ZipCode,
 			DealerName:     req.DealerName,
 			PhoneNumber:    req.PhoneNumber,
@@ -121,122 +260,208 @@ func SubmitCalls(w http.ResponseWriter, r *http.Request) {
 			CompetingPrice: competingPrice,
 		}
 
-		if isDealing {
-			log.Printf("Generated call request %d: user_id=%s, dealer=%s, phone=%s, model=%s %d, competing_price=$%d",
-				i+1, callID, req.DealerName, req.PhoneNumber, req.Model, req.Year, competingPrice)
-		} else {
-			log.Printf("Generated call request %d: user_id=%s, dealer=%s, phone=%s, model=%s %d (no existing deals)",
-				i+1, callID, req.DealerName, req.PhoneNumber, req.Model, req.Year)
-		}
-
-		// Store call in database
-		if err := database.CreateCall(req.UserID, agentRequests[i].CallID, req.Model, req.Year, req.ZipCode, req.DealerName, req.PhoneNumber, req.MSRP, req.ListingPrice); err != nil {
-			log.Printf("⚠️  Warning: Failed to store call in database: %v", err)
-		} else {
-			log.Printf("✅ Call stored in database: %s", agentRequests[i].CallID)
+		slog.InfoContext(r.Context(), "generated call request",
+			slog.Int("index", i+1), slog.String("call_id", callID), slog.String("dealer", req.DealerName),
+			logging.Phone("phone", req.PhoneNumber), slog.String("model", req.Model), slog.Int("year", req.Year),
+			slog.Bool("is_dealing", isDealing), slog.Int("competing_price", competingPrice))
+
+		newCalls[i] = database.NewCall{
+			UserID:       req.UserID,
+			CallID:       callID,
+			Make:         req.Make,
+			Model:        req.Model,
+			Year:         req.Year,
+			Condition:    req.Condition,
+			ZipCode:      req.ZipCode,
+			DealerName:   req.DealerName,
+			PhoneNumber:  req.PhoneNumber,
+			MSRP:         req.MSRP,
+			ListingPrice: req.ListingPrice,
+			TraceParent:  tracing.TraceParent(ctx),
 		}
+		span.End()
 	}
 
-	// Call the agent service
-	agentResponse, err := callAgentService(agentRequests)
+	// The agent request is stored with the calls, so a worker can send it long after this request ends
+	payload, err := json.Marshal(agentRequests)
 	if err != nil {
-		log.Printf("Error calling agent service: %v", err)
+		slog.ErrorContext(r.Context(), "failed to encode agent request", logging.Err(err))
+		h.refundCalls(w, r, quota)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(CallSubmitResponse{
 			Success: false,
-			Message: fmt.Sprintf("Failed to initiate calls: %v", err),
+			Message: "Failed to prepare calls",
 		})
 		return
 	}
 
-	// Return success response
-	w.WriteHeader(http.StatusOK)
+	// Store every call and its dispatch job together, so no call is placed without a row to record its result
+	jobID, err := h.store.EnqueueCalls(r.Context(), newCalls, database.NewCallJob{
+		Payload:     payload,
+		TraceParent: tracing.TraceParent(r.Context()),
+		RequestID:   logging.RequestID(r.Context()),
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to queue calls", slog.Int("calls", len(newCalls)), logging.Err(err))
+		h.refundCalls(w, r, quota)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(CallSubmitResponse{
+			Success: false,
+			Message: "Failed to store calls; none were placed",
+		})
+		return
+	}
+	slog.InfoContext(r.Context(), "calls queued", slog.Int64("job_id", jobID), slog.Int("calls", len(newCalls)))
+
+	if h.dispatcher != nil {
+		h.dispatcher.Notify()
+	}
+
+	// The calls are placed in the background; clients poll GET /api/calls for their status
+	w.WriteHeader(http.StatusAccepted)
 	json.NewEncoder(w).Encode(CallSubmitResponse{
 		Success: true,
-		Message: "Calls initiated successfully",
-		Data:    agentResponse,
+		Message: "Calls queued",
+		Data:    map[string]interface{}{"job_id": jobID},
+		CallIDs: callIDs,
+		Calls:   callOutcomes(callIDs, database.StatusPending),
 	})
 }
 
+// callOutcomes reports the same status for every call in a submission
+func callOutcomes(callIDs []string, status database.CallStatus) []CallOutcome {
+	outcomes := make([]CallOutcome, len(callIDs))
+	for i, callID := range callIDs {
+		outcomes[i] = CallOutcome{Index: i, CallID: callID, Status: status}
+	}
+	return outcomes
+}
+
 // generateUserID generates a unique user ID for each call
 func generateUserID() string {
 	// Generate UUID
 	return uuid.New().String()
 }
 
-// callAgentService makes a request to the agent service to initiate calls
-func callAgentService(requests []AgentCallRequest) (interface{}, error) {
-	agentURL := "https://unimplicitly-ebracteate-loma.ngrok-free.dev/calls/init"
+// CallFinishRequest represents the request from agent service when a call is finished
+type CallFinishRequest struct {
+	UserID      string `json:"user_id"`
+	IsAvailable bool   `json:"is_available"`
+	DealPrice   int    `json:"deal_price"`
+	Remarks     string `json:"remarks"`
+	// Status is the call's result; when empty it is completed or unavailable according to IsAvailable
+	Status string `json:"status,omitempty"`
+}
 
-	// Marshal requests to JSON
-	jsonData, err := json.Marshal(requests)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
+// CallStatusRequest represents a progress report from the agent service
+type CallStatusRequest struct {
+	// UserID is the call ID, named as in CallFinishRequest
+	UserID string `json:"user_id"`
+	// Status is ringing, in_progress or cancelled
+	Status string `json:"status"`
+	// Reason is recorded with the transition; optional
+	Reason string `json:"reason,omitempty"`
+}
 
-	log.Printf("Calling agent service at %s with payload: %s", agentURL, string(jsonData))
+// CallFinishResponse represents the response to the agent service
+type CallFinishResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
 
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+// allowCalls takes n calls from the caller's rate limits and daily quota, writing the error response if they do not fit
+// The remaining daily quota is reported in headers either way
+func (h *CallHandler) allowCalls(w http.ResponseWriter, r *http.Request, n int) (ratelimit.Decision, bool) {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		userID = anonymousUser
 	}
 
-	// Create request
-	req, err := http.NewRequest("POST", agentURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	tierName, tier := h.limiter.Tier(auth.Tier(r.Context()))
+	if n > tier.MaxBatch {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(CallSubmitResponse{
+			Success: false,
+			Message: fmt.Sprintf("At most %d calls can be submitted at once", tier.MaxBatch),
+		})
+		return ratelimit.Decision{}, false
 	}
 
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-
-	// Execute request
-	resp, err := client.Do(req)
+	decision, err := h.limiter.Allow(r.Context(), userID, tierName, n)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		slog.ErrorContext(r.Context(), "failed to check call quota", logging.Err(err))
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(CallSubmitResponse{
+			Success: false,
+			Message: "Failed to check call quota",
+		})
+		return decision, false
 	}
-	defer resp.Body.Close()
 
-	// Check status code
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		var errorBody bytes.Buffer
-		errorBody.ReadFrom(resp.Body)
-		return nil, fmt.Errorf("agent service returned status %d: %s", resp.StatusCode, errorBody.String())
+	w.Header().Set("X-Quota-Limit", strconv.Itoa(decision.DailyLimit))
+	w.Header().Set("X-Quota-Remaining", strconv.Itoa(decision.DailyRemaining))
+	if decision.Allowed {
+		return decision, true
 	}
 
-	// Parse response
-	var agentResponse interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&agentResponse); err != nil {
-		return nil, fmt.Errorf("failed to parse agent response: %w", err)
+	// Round up so clients retrying after Retry-After are not refused again
+	retryAfter := int((decision.RetryAfter + time.Second - 1) / time.Second)
+	metrics.RateLimited(decision.Reason)
+	slog.WarnContext(r.Context(), "call submission rate limited",
+		slog.String("reason", decision.Reason), slog.String("tier", decision.Tier),
+		slog.Int("requested", n), slog.Int("retry_after_seconds", retryAfter))
+
+	message := "Too many calls submitted, please retry later"
+	if decision.Reason == ratelimit.ReasonDaily {
+		message = fmt.Sprintf("Daily limit of %d calls reached (%d remaining)", decision.DailyLimit, decision.DailyRemaining)
 	}
 
-	log.Printf("Successfully initiated calls with agent service")
-	return agentResponse, nil
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(CallSubmitResponse{
+		Success: false,
+		Message: message,
+		Data: map[string]interface{}{
+			"reason":              decision.Reason,
+			"tier":                decision.Tier,
+			"retry_after_seconds": retryAfter,
+			"daily_limit":         decision.DailyLimit,
+			"daily_remaining":     decision.DailyRemaining,
+		},
+	})
+	return decision, false
 }
 
-// CallFinishRequest represents the request from agent service when a call is finished
-type CallFinishRequest struct {
-	UserID      string `json:"user_id"`
-	IsAvailable bool   `json:"is_available"`
-	DealPrice   int    `json:"deal_price"`
-	Remarks     string `json:"remarks"`
-}
+// refundCalls gives back the quota taken for a submission that failed before its calls were queued
+// The refund outlives the request, since the client going away is one of the reasons it fails
+func (h *CallHandler) refundCalls(w http.ResponseWriter, r *http.Request, quota ratelimit.Decision) {
+	if h.limiter == nil {
+		return
+	}
 
-// CallFinishResponse represents the response to the agent service
-type CallFinishResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	refunded, err := h.limiter.Refund(context.WithoutCancel(r.Context()), quota)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to refund call quota", logging.Err(err))
+		return
+	}
+	w.Header().Set("X-Quota-Remaining", strconv.Itoa(refunded.DailyRemaining))
 }
 
 // FinishCall handles POST /api/calls/finish
 // Receives notification from agent service when a call is completed
-func FinishCall(w http.ResponseWriter, r *http.Request) {
+// The body must be signed with a shared secret when webhook verification is configured
+func (h *CallHandler) FinishCall(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	body, ok := h.readAgentCallback(w, r)
+	if !ok {
+		return
+	}
+
 	// Parse request body
 	var request CallFinishRequest
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		log.Printf("Error decoding call finish request: %v", err)
+	if err := json.Unmarshal(body, &request); err != nil {
+		slog.WarnContext(r.Context(), "invalid call finish body", logging.Err(err))
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(CallFinishResponse{
 			Success: false,
@@ -255,16 +480,63 @@ func FinishCall(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	status := database.ResultStatus(request.IsAvailable)
+	if request.Status != "" {
+		parsed, err := database.ParseCallStatus(request.Status)
+		if err != nil || !parsed.IsResult() {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(CallFinishResponse{
+				Success: false,
+				Message: fmt.Sprintf("status must be one of completed, unavailable, no_answer, voicemail or failed, got %q", request.Status),
+			})
+			return
+		}
+		status = parsed
+	}
+
+	// Link this callback to the trace that dispatched the call
+	h.linkCallTrace(r.Context(), request.UserID)
+
 	// Log the call completion details
-	log.Printf("Call finished - UserID: %s, IsAvailable: %t, DealPrice: %d, Remarks: %s",
-		request.UserID, request.IsAvailable, request.DealPrice, request.Remarks)
+	slog.InfoContext(r.Context(), "call finished",
+		slog.String("call_id", request.UserID), slog.String("status", string(status)), slog.Bool("is_available", request.IsAvailable),
+		slog.Int("deal_price", request.DealPrice), logging.Remarks("remarks", request.Remarks))
 
 	// Update call in database
-	if err := database.UpdateCallResult(request.UserID, request.IsAvailable, request.DealPrice, request.Remarks); err != nil {
-		log.Printf("⚠️  Warning: Failed to update call in database: %v", err)
-	} else {
-		log.Printf("✅ Call updated in database: %s", request.UserID)
+	err := h.store.UpdateCallResult(r.Context(), request.UserID, database.CallResult{
+		Status:      status,
+		IsAvailable: request.IsAvailable,
+		DealPrice:   request.DealPrice,
+		Remarks:     request.Remarks,
+	})
+	switch {
+	case errors.Is(err, database.ErrCallNotFound):
+		slog.WarnContext(r.Context(), "call finish for unknown call", slog.String("call_id", request.UserID))
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(CallFinishResponse{
+			Success: false,
+			Message: "Call not found",
+		})
+		return
+	case errors.Is(err, database.ErrInvalidTransition):
+		// Typically a duplicate callback for a call that already has its result
+		slog.WarnContext(r.Context(), "rejected call status transition", slog.String("call_id", request.UserID), logging.Err(err))
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(CallFinishResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "failed to update call", slog.String("call_id", request.UserID), logging.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(CallFinishResponse{
+			Success: false,
+			Message: "Failed to record call completion",
+		})
+		return
 	}
+	slog.InfoContext(r.Context(), "call updated", slog.String("call_id", request.UserID))
 
 	// Return success response
 	w.WriteHeader(http.StatusOK)
@@ -274,11 +546,246 @@ func FinishCall(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// ReportCallStatus handles POST /api/calls/status
+// Receives progress from the agent service while a call is under way: ringing, in_progress or cancelled
+// The body is signed like the finish callback
+func (h *CallHandler) ReportCallStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	body, ok := h.readAgentCallback(w, r)
+	if !ok {
+		return
+	}
+
+	var request CallStatusRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		slog.WarnContext(r.Context(), "invalid call status body", logging.Err(err))
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(CallFinishResponse{
+			Success: false,
+			Message: fmt.Sprintf("Invalid request body: %v", err),
+		})
+		return
+	}
+
+	if request.UserID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(CallFinishResponse{
+			Success: false,
+			Message: "user_id is required",
+		})
+		return
+	}
+
+	status, err := database.ParseCallStatus(request.Status)
+	if err != nil || !status.IsProgress() {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(CallFinishResponse{
+			Success: false,
+			Message: fmt.Sprintf("status must be one of ringing, in_progress or cancelled, got %q", request.Status),
+		})
+		return
+	}
+
+	h.linkCallTrace(r.Context(), request.UserID)
+
+	reason := request.Reason
+	if reason == "" {
+		reason = "reported by agent service"
+	}
+	slog.InfoContext(r.Context(), "call status reported",
+		slog.String("call_id", request.UserID), slog.String("status", string(status)), slog.String("reason", reason))
+
+	err = h.transitionReportedCall(r.Context(), request.UserID, status, reason)
+	switch {
+	case errors.Is(err, database.ErrCallNotFound):
+		slog.WarnContext(r.Context(), "call status for unknown call", slog.String("call_id", request.UserID))
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(CallFinishResponse{
+			Success: false,
+			Message: "Call not found",
+		})
+		return
+	case errors.Is(err, database.ErrInvalidTransition):
+		// Typically a report that arrived after the call's result
+		slog.WarnContext(r.Context(), "rejected call status transition", slog.String("call_id", request.UserID), logging.Err(err))
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(CallFinishResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "failed to update call status", slog.String("call_id", request.UserID), logging.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(CallFinishResponse{
+			Success: false,
+			Message: "Failed to record call status",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(CallFinishResponse{
+		Success: true,
+		Message: "Call status recorded successfully",
+	})
+}
+
+// transitionReportedCall moves a call to a status the agent service reported
+// A progress report can arrive before the dispatcher has recorded that the agent accepted the call; since the
+// report shows it did, the call is first moved from pending to dispatched, as the dispatcher would have done
+func (h *CallHandler) transitionReportedCall(ctx context.Context, callID string, status database.CallStatus, reason string) error {
+	err := h.store.TransitionCalls(ctx, []string{callID}, status, reason)
+
+	var transitionErr *database.TransitionError
+	if !errors.As(err, &transitionErr) || transitionErr.From != database.StatusPending {
+		return err
+	}
+
+	// The dispatcher may record the dispatch in between, which leaves the call where it needs to be
+	err = h.store.TransitionCalls(ctx, []string{callID}, database.StatusDispatched, "agent service reported progress")
+	if err != nil && !errors.Is(err, database.ErrInvalidTransition) {
+		return err
+	}
+	return h.store.TransitionCalls(ctx, []string{callID}, status, reason)
+}
+
+// readAgentCallback reads an agent callback body and verifies its signature, writing the error response if either fails
+func (h *CallHandler) readAgentCallback(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxFinishBodyBytes))
+	if err != nil {
+		slog.WarnContext(r.Context(), "failed to read agent callback body", logging.Err(err))
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(CallFinishResponse{
+			Success: false,
+			Message: "Failed to read request body",
+		})
+		return nil, false
+	}
+
+	// Verify the agent's signature before trusting anything in the body
+	if h.webhook != nil {
+		if err := h.webhook.Verify(r.Header, body); err != nil {
+			slog.WarnContext(r.Context(), "rejected agent callback", logging.Err(err))
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(CallFinishResponse{
+				Success: false,
+				Message: "Invalid webhook signature",
+			})
+			return nil, false
+		}
+	}
+
+	return body, true
+}
+
+// linkCallTrace links an agent callback's span to the trace that dispatched the call
+func (h *CallHandler) linkCallTrace(ctx context.Context, callID string) {
+	traceParent, err := h.store.GetCallTraceParent(ctx, callID)
+	if err == nil && traceParent != "" {
+		tracing.LinkTraceParent(ctx, traceParent, attribute.String("call.id", callID))
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("call.id", callID))
+}
+
+// GetCallTransitions handles GET /api/calls/transitions
+// Returns the status changes of the call in call_id, oldest first
+func (h *CallHandler) GetCallTransitions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	callID := r.URL.Query().Get("call_id")
+	if callID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "call_id parameter is required",
+		})
+		return
+	}
+
+	// Authenticated callers may only read their own calls; another user's call is reported as not found
+	if userID, ok := auth.UserID(r.Context()); ok {
+		owned, err := h.ownsCall(r.Context(), userID, callID)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "failed to retrieve calls", slog.String("user_id", userID), logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"error":   "Failed to retrieve call transitions",
+			})
+			return
+		}
+		if !owned {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"error":   "Call not found",
+			})
+			return
+		}
+	}
+
+	transitions, err := h.store.GetCallTransitions(r.Context(), callID)
+	if errors.Is(err, database.ErrCallNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Call not found",
+		})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to retrieve call transitions", slog.String("call_id", callID), logging.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Failed to retrieve call transitions",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"count":   len(transitions),
+		"data":    transitions,
+	})
+}
+
+// ownsCall reports whether callID is one of the user's calls
+func (h *CallHandler) ownsCall(ctx context.Context, userID, callID string) (bool, error) {
+	calls, err := h.store.GetCallsForUser(ctx, userID, "")
+	if err != nil {
+		return false, err
+	}
+	for _, call := range calls {
+		if call.CallID != nil && *call.CallID == callID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // GetCall retrieves a specific call by user ID
-func GetCall(w http.ResponseWriter, r *http.Request) {
+func (h *CallHandler) GetCall(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	userID := r.URL.Query().Get("user_id")
+
+	// Authenticated callers may only read their own calls; user_id defaults to the caller
+	if callerID, ok := auth.UserID(r.Context()); ok {
+		if userID != "" && userID != callerID {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"error":   "Cannot read another user's calls",
+			})
+			return
+		}
+		userID = callerID
+	}
+
 	if userID == "" {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]interface{}{
@@ -288,8 +795,8 @@ func GetCall(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	call, err := database.GetCallByUserID(userID)
-	if err != nil {
+	call, err := h.store.GetCallByUserID(r.Context(), userID)
+	if errors.Is(err, database.ErrCallNotFound) {
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": false,
@@ -297,6 +804,15 @@ func GetCall(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to retrieve call", slog.String("user_id", userID), logging.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Failed to retrieve call",
+		})
+		return
+	}
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -306,19 +822,39 @@ func GetCall(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetAllCalls retrieves all calls with optional status filter
-func GetAllCalls(w http.ResponseWriter, r *http.Request) {
+func (h *CallHandler) GetAllCalls(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	status := r.URL.Query().Get("status")
-
-	var calls []database.Call
-	var err error
+	var status database.CallStatus
+	if value := r.URL.Query().Get("status"); value != "" {
+		parsed, err := database.ParseCallStatus(value)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		status = parsed
+	}
 
-	if status != "" {
-		calls, err = database.GetCallsByStatus(status)
-	} else {
-		calls, err = database.GetAllCalls()
+	// Callers only ever see one user's calls: their own when authenticated,
+	// or those of the user_id they send when authentication is disabled
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		userID = r.URL.Query().Get("user_id")
 	}
+	if userID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "user_id parameter is required",
+		})
+		return
+	}
+
+	calls, err := h.store.GetCallsForUser(r.Context(), userID, status)
 
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -0,0 +1,502 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"hackutd2025/backend/internal/auth"
+	"hackutd2025/backend/internal/database"
+	"hackutd2025/backend/internal/ratelimit"
+)
+
+// testCall is a valid call submission
+var testCall = CallSubmitRequest{
+	UserID:       "user-1",
+	Model:        "RAV4",
+	Year:         2024,
+	ZipCode:      "75007",
+	DealerName:   "Toyota of Lewisville",
+	PhoneNumber:  "+15555550100",
+	MSRP:         32000,
+	ListingPrice: 31000,
+}
+
+// postJSON sends body to handler as a POST and returns the recorded response
+func postJSON(t *testing.T, handler http.HandlerFunc, path string, body any, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+	payload, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+// submit posts calls and decodes the response, failing the test unless it has status want
+func submit(t *testing.T, h *CallHandler, calls []CallSubmitRequest, header http.Header, want int) CallSubmitResponse {
+	t.Helper()
+	rec := postJSON(t, h.SubmitCalls, "/api/calls/submit", calls, header)
+	if rec.Code != want {
+		t.Fatalf("submit status = %d, want %d (body %s)", rec.Code, want, rec.Body)
+	}
+
+	var response CallSubmitResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("decode submit response: %v", err)
+	}
+	return response
+}
+
+// finish posts a call result and returns the recorded response
+func finish(t *testing.T, h *CallHandler, request CallFinishRequest) *httptest.ResponseRecorder {
+	t.Helper()
+	return postJSON(t, h.FinishCall, "/api/calls/finish", request, nil)
+}
+
+// postSigned posts body to FinishCall with the given signature headers
+func postSigned(h *CallHandler, body []byte, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/calls/finish", bytes.NewReader(body))
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	rec := httptest.NewRecorder()
+	h.FinishCall(rec, req)
+	return rec
+}
+
+// flakyResultStore fails the first result update, as a database hiccup would
+type flakyResultStore struct {
+	*database.MemoryCallStore
+	failed bool
+}
+
+func (s *flakyResultStore) UpdateCallResult(ctx context.Context, callID string, result database.CallResult) error {
+	if !s.failed {
+		s.failed = true
+		return errors.New("connection reset")
+	}
+	return s.MemoryCallStore.UpdateCallResult(ctx, callID, result)
+}
+
+// unavailableStore fails to queue calls while down is set, as an unreachable database would
+type unavailableStore struct {
+	*database.MemoryCallStore
+	down bool
+}
+
+func (s *unavailableStore) EnqueueCalls(ctx context.Context, calls []database.NewCall, job database.NewCallJob) (int64, error) {
+	if s.down {
+		return 0, errors.New("connection refused")
+	}
+	return s.MemoryCallStore.EnqueueCalls(ctx, calls, job)
+}
+
+// newTestLimiter allows each user a burst and a daily quota of daily calls
+func newTestLimiter(t *testing.T, store ratelimit.QuotaStore, daily int) *ratelimit.Limiter {
+	t.Helper()
+	limiter, err := ratelimit.New(store, ratelimit.Options{
+		GlobalPerMinute: 600,
+		GlobalBurst:     100,
+		Tiers:           map[string]ratelimit.Tier{"standard": {PerMinute: 60, Burst: daily, Daily: daily, MaxBatch: daily}},
+		DefaultTier:     "standard",
+	})
+	if err != nil {
+		t.Fatalf("ratelimit.New: %v", err)
+	}
+	return limiter
+}
+
+// dispatchAll marks every queued job as accepted by the agent service, as a dispatcher worker would
+func dispatchAll(t *testing.T, store *database.MemoryCallStore) {
+	t.Helper()
+	ctx := context.Background()
+	jobs, err := store.ClaimCallJobs(ctx, 100, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimCallJobs: %v", err)
+	}
+	for _, job := range jobs {
+		if err := store.CompleteCallJob(ctx, job); err != nil {
+			t.Fatalf("CompleteCallJob: %v", err)
+		}
+	}
+}
+
+// callStatus returns the stored status of a call
+func callStatus(t *testing.T, store *database.MemoryCallStore, callID string) database.CallStatus {
+	t.Helper()
+	calls, err := store.GetAllCalls(context.Background())
+	if err != nil {
+		t.Fatalf("GetAllCalls: %v", err)
+	}
+	for _, call := range calls {
+		if *call.CallID == callID {
+			return *call.Status
+		}
+	}
+	t.Fatalf("call %s not stored", callID)
+	return ""
+}
+
+func TestSubmitCallsQueuesCalls(t *testing.T) {
+	store := database.NewMemoryCallStore()
+	h := NewCallHandler(store, CallHandlerOptions{})
+
+	second := testCall
+	second.DealerName = "Toyota of Dallas"
+	response := submit(t, h, []CallSubmitRequest{testCall, second}, nil, http.StatusAccepted)
+
+	if len(response.CallIDs) != 2 || len(response.Calls) != 2 {
+		t.Fatalf("call IDs = %v, outcomes = %v, want 2 of each", response.CallIDs, response.Calls)
+	}
+	for _, callID := range response.CallIDs {
+		if status := callStatus(t, store, callID); status != database.StatusPending {
+			t.Errorf("call %s status = %s, want pending", callID, status)
+		}
+	}
+
+	jobs, err := store.ClaimCallJobs(context.Background(), 10, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimCallJobs: %v", err)
+	}
+	if len(jobs) != 1 || len(jobs[0].CallIDs) != 2 {
+		t.Fatalf("jobs = %+v, want one job for both calls", jobs)
+	}
+
+	var payload []AgentCallRequest
+	if err := json.Unmarshal(jobs[0].Payload, &payload); err != nil {
+		t.Fatalf("decode job payload: %v", err)
+	}
+	if payload[0].CallID != response.CallIDs[0] || payload[0].Make != "toyota" || payload[0].Condition != "new" {
+		t.Errorf("payload[0] = %+v, want call %s for a new toyota", payload[0], response.CallIDs[0])
+	}
+}
+
+func TestSubmitCallsRejectsInvalidRequests(t *testing.T) {
+	h := NewCallHandler(database.NewMemoryCallStore(), CallHandlerOptions{})
+
+	missingPhone := testCall
+	missingPhone.PhoneNumber = ""
+	badCondition := testCall
+	badCondition.Condition = "salvage"
+
+	tests := []struct {
+		name  string
+		calls []CallSubmitRequest
+	}{
+		{"empty", []CallSubmitRequest{}},
+		{"missing field", []CallSubmitRequest{missingPhone}},
+		{"bad condition", []CallSubmitRequest{badCondition}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			submit(t, h, tt.calls, nil, http.StatusBadRequest)
+		})
+	}
+}
+
+func TestFinishCallRecordsResult(t *testing.T) {
+	store := database.NewMemoryCallStore()
+	h := NewCallHandler(store, CallHandlerOptions{})
+	callID := submit(t, h, []CallSubmitRequest{testCall}, nil, http.StatusAccepted).CallIDs[0]
+	dispatchAll(t, store)
+
+	rec := finish(t, h, CallFinishRequest{UserID: callID, IsAvailable: true, DealPrice: 30500, Remarks: "in stock"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("finish status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body)
+	}
+
+	if status := callStatus(t, store, callID); status != database.StatusCompleted {
+		t.Errorf("status = %s, want completed", status)
+	}
+	best, ok, err := store.GetBestDealForCar(context.Background(), "Toyota", "RAV4", 2024, "new", "75007")
+	if err != nil || !ok || best != 30500 {
+		t.Errorf("best deal = %d, %t, %v; want 30500", best, ok, err)
+	}
+}
+
+func TestFinishCallBeforeDispatchRecorded(t *testing.T) {
+	store := database.NewMemoryCallStore()
+	h := NewCallHandler(store, CallHandlerOptions{})
+	callID := submit(t, h, []CallSubmitRequest{testCall}, nil, http.StatusAccepted).CallIDs[0]
+
+	// The agent reports the result before the dispatcher has recorded that the batch was accepted
+	if rec := finish(t, h, CallFinishRequest{UserID: callID, Status: "voicemail"}); rec.Code != http.StatusOK {
+		t.Fatalf("finish status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body)
+	}
+	dispatchAll(t, store)
+
+	if status := callStatus(t, store, callID); status != database.StatusVoicemail {
+		t.Errorf("status = %s, want voicemail", status)
+	}
+}
+
+func TestFinishCallUnknownCall(t *testing.T) {
+	h := NewCallHandler(database.NewMemoryCallStore(), CallHandlerOptions{})
+
+	rec := finish(t, h, CallFinishRequest{UserID: "no-such-call", IsAvailable: true})
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestFinishCallInvalidStatus(t *testing.T) {
+	store := database.NewMemoryCallStore()
+	h := NewCallHandler(store, CallHandlerOptions{})
+	callID := submit(t, h, []CallSubmitRequest{testCall}, nil, http.StatusAccepted).CallIDs[0]
+
+	for _, status := range []string{"ringing", "bogus"} {
+		rec := finish(t, h, CallFinishRequest{UserID: callID, Status: status})
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("status %q: got %d, want %d", status, rec.Code, http.StatusBadRequest)
+		}
+	}
+	if status := callStatus(t, store, callID); status != database.StatusPending {
+		t.Errorf("status = %s, want pending", status)
+	}
+}
+
+func TestFinishCallConflict(t *testing.T) {
+	store := database.NewMemoryCallStore()
+	h := NewCallHandler(store, CallHandlerOptions{})
+	callID := submit(t, h, []CallSubmitRequest{testCall}, nil, http.StatusAccepted).CallIDs[0]
+	dispatchAll(t, store)
+
+	if rec := finish(t, h, CallFinishRequest{UserID: callID, Status: "no_answer"}); rec.Code != http.StatusOK {
+		t.Fatalf("first finish status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	rec := finish(t, h, CallFinishRequest{UserID: callID, IsAvailable: true, DealPrice: 1})
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("second finish status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	if status := callStatus(t, store, callID); status != database.StatusNoAnswer {
+		t.Errorf("status = %s, want no_answer", status)
+	}
+}
+
+func TestFinishCallSignedRetryAfterFailure(t *testing.T) {
+	store := &flakyResultStore{MemoryCallStore: database.NewMemoryCallStore()}
+	h := NewCallHandler(store, CallHandlerOptions{
+		Webhook: auth.NewWebhookVerifier([]string{"secret"}, 5*time.Minute),
+	})
+	callID := submit(t, h, []CallSubmitRequest{testCall}, nil, http.StatusAccepted).CallIDs[0]
+	dispatchAll(t, store.MemoryCallStore)
+
+	body, _ := json.Marshal(CallFinishRequest{UserID: callID, IsAvailable: true, DealPrice: 30500})
+	header := http.Header{}
+	now := time.Now()
+	header.Set(auth.SignatureTimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	header.Set(auth.SignatureHeader, auth.SignWebhook("secret", now, body))
+
+	if rec := postSigned(h, body, header); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("first delivery status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	// The agent retries the identical signed request; it is not mistaken for a replay
+	if rec := postSigned(h, body, header); rec.Code != http.StatusOK {
+		t.Fatalf("retry status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body)
+	}
+	// Once the result is recorded, the same request delivered again changes nothing
+	if rec := postSigned(h, body, header); rec.Code != http.StatusConflict {
+		t.Fatalf("replay status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	if unsigned := postSigned(h, body, nil); unsigned.Code != http.StatusUnauthorized {
+		t.Fatalf("unsigned status = %d, want %d", unsigned.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestSubmitCallsRefundsQuotaWhenNotQueued(t *testing.T) {
+	store := &unavailableStore{MemoryCallStore: database.NewMemoryCallStore(), down: true}
+	h := NewCallHandler(store, CallHandlerOptions{Limiter: newTestLimiter(t, store, 2)})
+	calls := []CallSubmitRequest{testCall, testCall}
+
+	rec := postJSON(t, h.SubmitCalls, "/api/calls/submit", calls, nil)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if remaining := rec.Header().Get("X-Quota-Remaining"); remaining != "2" {
+		t.Errorf("X-Quota-Remaining = %q, want 2", remaining)
+	}
+
+	// The retry fits in the quota the failed attempt gave back
+	store.down = false
+	rec = postJSON(t, h.SubmitCalls, "/api/calls/submit", calls, nil)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("retry status = %d, want %d (body %s)", rec.Code, http.StatusAccepted, rec.Body)
+	}
+	if remaining := rec.Header().Get("X-Quota-Remaining"); remaining != "0" {
+		t.Errorf("X-Quota-Remaining after retry = %q, want 0", remaining)
+	}
+}
+
+func TestReportCallStatus(t *testing.T) {
+	store := database.NewMemoryCallStore()
+	h := NewCallHandler(store, CallHandlerOptions{})
+	callID := submit(t, h, []CallSubmitRequest{testCall}, nil, http.StatusAccepted).CallIDs[0]
+	dispatchAll(t, store)
+
+	report := func(request CallStatusRequest) int {
+		t.Helper()
+		return postJSON(t, h.ReportCallStatus, "/api/calls/status", request, nil).Code
+	}
+
+	tests := []struct {
+		name    string
+		request CallStatusRequest
+		want    int
+	}{
+		{"ringing", CallStatusRequest{UserID: callID, Status: "ringing"}, http.StatusOK},
+		{"answered", CallStatusRequest{UserID: callID, Status: "in_progress", Reason: "dealer picked up"}, http.StatusOK},
+		{"back to ringing", CallStatusRequest{UserID: callID, Status: "ringing"}, http.StatusConflict},
+		{"result", CallStatusRequest{UserID: callID, Status: "completed"}, http.StatusBadRequest},
+		{"unknown status", CallStatusRequest{UserID: callID, Status: "bogus"}, http.StatusBadRequest},
+		{"missing call", CallStatusRequest{Status: "ringing"}, http.StatusBadRequest},
+		{"unknown call", CallStatusRequest{UserID: "no-such-call", Status: "ringing"}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if got := report(tt.request); got != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+
+	if rec := finish(t, h, CallFinishRequest{UserID: callID, IsAvailable: true, DealPrice: 30500}); rec.Code != http.StatusOK {
+		t.Fatalf("finish status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body)
+	}
+	if status := callStatus(t, store, callID); status != database.StatusCompleted {
+		t.Errorf("status = %s, want completed", status)
+	}
+}
+
+func TestReportCallStatusBeforeDispatchRecorded(t *testing.T) {
+	store := database.NewMemoryCallStore()
+	h := NewCallHandler(store, CallHandlerOptions{})
+	callID := submit(t, h, []CallSubmitRequest{testCall}, nil, http.StatusAccepted).CallIDs[0]
+
+	// The agent reports ringing before the dispatcher has recorded that it accepted the call
+	rec := postJSON(t, h.ReportCallStatus, "/api/calls/status", CallStatusRequest{UserID: callID, Status: "ringing"}, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body)
+	}
+
+	transitions, err := store.GetCallTransitions(context.Background(), callID)
+	if err != nil {
+		t.Fatalf("GetCallTransitions: %v", err)
+	}
+	want := []database.CallStatus{database.StatusPending, database.StatusDispatched, database.StatusRinging}
+	if len(transitions) != len(want) {
+		t.Fatalf("got %d transitions, want %d", len(transitions), len(want))
+	}
+	for i, transition := range transitions {
+		if transition.To != want[i] {
+			t.Errorf("transition %d to %s, want %s", i, transition.To, want[i])
+		}
+	}
+
+	// Recording the dispatch afterwards leaves the call where the agent's report put it
+	dispatchAll(t, store)
+	if status := callStatus(t, store, callID); status != database.StatusRinging {
+		t.Errorf("status after dispatch = %s, want ringing", status)
+	}
+}
+
+func TestGetCallTransitions(t *testing.T) {
+	store := database.NewMemoryCallStore()
+	h := NewCallHandler(store, CallHandlerOptions{})
+	callID := submit(t, h, []CallSubmitRequest{testCall}, nil, http.StatusAccepted).CallIDs[0]
+	dispatchAll(t, store)
+	postJSON(t, h.ReportCallStatus, "/api/calls/status", CallStatusRequest{UserID: callID, Status: "ringing"}, nil)
+	finish(t, h, CallFinishRequest{UserID: callID, Status: "no_answer"})
+
+	get := func(ctx context.Context, query string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/calls/transitions?"+query, nil).WithContext(ctx)
+		rec := httptest.NewRecorder()
+		h.GetCallTransitions(rec, req)
+		return rec
+	}
+
+	rec := get(context.Background(), "call_id="+callID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body)
+	}
+	var response struct {
+		Data []database.CallTransition `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := []database.CallStatus{database.StatusPending, database.StatusDispatched, database.StatusRinging, database.StatusNoAnswer}
+	if len(response.Data) != len(want) {
+		t.Fatalf("got %d transitions, want %d", len(response.Data), len(want))
+	}
+	for i, transition := range response.Data {
+		if transition.To != want[i] {
+			t.Errorf("transition %d to %s, want %s", i, transition.To, want[i])
+		}
+	}
+
+	if rec := get(context.Background(), "call_id=no-such-call"); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown call status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if rec := get(context.Background(), ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("missing call_id status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	// testCall belongs to user-1, so another user cannot see its history
+	if rec := get(auth.WithUserID(context.Background(), "user-2"), "call_id="+callID); rec.Code != http.StatusNotFound {
+		t.Errorf("another user's call status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestGetAllCallsOnlyReturnsOneUsersCalls(t *testing.T) {
+	store := database.NewMemoryCallStore()
+	h := NewCallHandler(store, CallHandlerOptions{})
+	other := testCall
+	other.UserID = "user-2"
+	submit(t, h, []CallSubmitRequest{testCall, testCall}, nil, http.StatusAccepted)
+	submit(t, h, []CallSubmitRequest{other}, nil, http.StatusAccepted)
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		query      string
+		wantStatus int
+		wantCount  int
+	}{
+		{"authenticated", auth.WithUserID(context.Background(), "user-1"), "", http.StatusOK, 2},
+		{"authenticated ignores user_id", auth.WithUserID(context.Background(), "user-2"), "user_id=user-1", http.StatusOK, 1},
+		{"auth disabled with user_id", context.Background(), "user_id=user-2", http.StatusOK, 1},
+		{"auth disabled with status", context.Background(), "user_id=user-1&status=dispatched", http.StatusOK, 0},
+		{"auth disabled without user_id", context.Background(), "", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/calls?"+tt.query, nil).WithContext(tt.ctx)
+			rec := httptest.NewRecorder()
+			h.GetAllCalls(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body)
+			}
+			var response struct {
+				Count int `json:"count"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if response.Count != tt.wantCount {
+				t.Errorf("count = %d, want %d", response.Count, tt.wantCount)
+			}
+		})
+	}
+}
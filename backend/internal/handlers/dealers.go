@@ -2,8 +2,14 @@ package handlers
 
 import (
 	"encoding/json"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"strings"
+
+	"hackutd2025/backend/internal/listings"
+	"hackutd2025/backend/internal/logging"
+	"hackutd2025/backend/internal/models"
 )
 
 // DealerSearchRequest represents the search criteria from frontend
@@ -11,6 +17,7 @@ type DealerSearchRequest struct {
 	Make        string `json:"make"`
 	Model       string `json:"model"`
 	Version     string `json:"version"`
+	Condition   string `json:"condition"`
 	ZipCode     string `json:"zipCode"`
 	RadiusMiles int    `json:"radiusMiles"`
 }
@@ -42,14 +49,14 @@ type ErrorResponse struct {
 }
 
 // SearchDealers handles POST /api/dealers/search
-// This will integrate with CARFAX API in the future
+// Fetches inventory from the listing provider and returns one entry per matching listing
 func SearchDealers(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	// Parse request body
 	var req DealerSearchRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("Error decoding request: %v", err)
+		slog.WarnContext(r.Context(), "invalid dealer search body", logging.Err(err))
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(ErrorResponse{
 			Success: false,
@@ -70,17 +77,57 @@ func SearchDealers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Printf("Searching for: %s %s %s near %s (radius: %d miles)",
-		req.Make, req.Model, req.Version, req.ZipCode, req.RadiusMiles)
+	condition, err := listings.ParseCondition(req.Condition)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{
+			Success: false,
+			Error:   "Invalid condition",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if req.RadiusMiles <= 0 {
+		req.RadiusMiles = 50 // Same default as GetSellers
+	}
 
-	// TODO: Call CARFAX API here
-	// For now, return mock data
-	mockDealers := generateMockDealers(req)
+	slog.InfoContext(r.Context(), "searching dealers",
+		slog.String("condition", string(condition)), slog.String("make", req.Make), slog.String("model", req.Model),
+		slog.String("version", req.Version), slog.String("zip", req.ZipCode), slog.Int("radius", req.RadiusMiles))
+
+	// Fetch inventory through the same provider as GetSellers
+	carfaxResponse, err := listingProvider.Search(r.Context(), listings.Query{
+		Zip:       req.ZipCode,
+		Radius:    req.RadiusMiles,
+		Make:      req.Make,
+		Model:     req.Model,
+		Condition: condition,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to fetch listings", slog.String("provider", listingProvider.Name()), logging.Err(err))
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch dealers",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	filter := listings.Filter{Trims: []string{req.Version}}
+	dealers := make([]DealerResponse, 0, len(carfaxResponse.Listings))
+	for _, listing := range filter.Apply(carfaxResponse.Listings) {
+		dealers = append(dealers, toDealerResponse(listing))
+	}
+
+	slog.InfoContext(r.Context(), "dealer search matched",
+		slog.Int("matched", len(dealers)), slog.Int("fetched", len(carfaxResponse.Listings)), slog.String("version", req.Version))
 
 	response := DealerSearchResponse{
 		Success: true,
-		Dealers: mockDealers,
-		Count:   len(mockDealers),
+		Dealers: dealers,
+		Count:   len(dealers),
 		Message: "Successfully retrieved dealers",
 	}
 
@@ -88,62 +135,74 @@ func SearchDealers(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
-// generateMockDealers creates test dealer data
-// This will be replaced with real CARFAX API calls
-func generateMockDealers(req DealerSearchRequest) []DealerResponse {
-	basePrice := 35000.0
-
-	// Generate different dealers based on the car model
-	dealerNames := []string{
-		req.Make + " of Dallas",
-		req.Make + " of Plano",
-		req.Make + " of Frisco",
-		"AutoNation " + req.Make,
-		"Sewell " + req.Make,
+// GetDealers handles GET /api/dealers
+// Accepts the same search and filter parameters as GetSellers and groups the matches by dealer
+func GetDealers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	queryParams := r.URL.Query()
+	query, err := parseListingQuery(queryParams)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: err.Error()})
+		return
 	}
 
-	dealers := make([]DealerResponse, 0)
-
-	for i, name := range dealerNames {
-		discount := float64(i) * 500 // Increasing discount per dealer
-		dealers = append(dealers, DealerResponse{
-			DealerName:      name,
-			Phone:           generateMockPhone(i),
-			Address:         generateMockAddress(i, req.ZipCode),
-			MSRP:            basePrice,
-			DiscountedPrice: basePrice - discount,
-			MPG:             28 + (i % 3),
-			Distance:        5.5 + float64(i)*3.2,
-		})
+	filter, err := parseListingFilter(queryParams)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: err.Error()})
+		return
 	}
 
-	return dealers
+	response, err := listingProvider.Search(r.Context(), query)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to fetch listings", slog.String("provider", listingProvider.Name()), logging.Err(err))
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: fmt.Sprintf("Failed to fetch data: %v", err)})
+		return
+	}
+
+	matched := filter.Apply(response.Listings)
+	dealers := listings.GroupByDealer(matched)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.DealersResponse{
+		SearchArea:   response.SearchArea,
+		Dealers:      dealers,
+		TotalDealers: len(dealers),
+		TotalFetched: len(response.Listings),
+		TotalMatched: len(matched),
+	})
 }
 
-func generateMockPhone(index int) string {
-	phones := []string{
-		"469-535-8000",
-		"972-468-1800",
-		"214-291-2000",
-		"469-209-1000",
-		"972-661-9000",
+// toDealerResponse maps a CARFAX listing and its dealer to the frontend dealer shape
+func toDealerResponse(listing models.Listing) DealerResponse {
+	return DealerResponse{
+		DealerName:      listing.Dealer.Name,
+		Phone:           listing.Dealer.Phone,
+		Address:         formatDealerAddress(listing.Dealer),
+		MSRP:            float64(listing.Msrp),
+		DiscountedPrice: float64(listing.CurrentPrice),
+		MPG:             listing.MpgCombined,
+		Distance:        listing.DistanceToDealer,
 	}
-	if index < len(phones) {
-		return phones[index]
-	}
-	return "972-000-0000"
 }
 
-func generateMockAddress(index int, zipCode string) string {
-	addresses := []string{
-		"6000 Central Expy, Plano, TX 75023",
-		"5300 N Central Expy, Dallas, TX 75205",
-		"8400 Gaylord Pkwy, Frisco, TX 75034",
-		"14900 N Dallas Pkwy, Dallas, TX 75254",
-		"2700 N Stemmons Fwy, Dallas, TX 75207",
+// formatDealerAddress joins the dealer's street, city, state and zip into a single line
+func formatDealerAddress(dealer models.Dealer) string {
+	parts := make([]string, 0, 3)
+	if dealer.Address != "" {
+		parts = append(parts, dealer.Address)
+	}
+	if dealer.City != "" {
+		parts = append(parts, dealer.City)
 	}
-	if index < len(addresses) {
-		return addresses[index]
+
+	stateZip := strings.TrimSpace(dealer.State + " " + dealer.Zip)
+	if stateZip != "" {
+		parts = append(parts, stateZip)
 	}
-	return "Unknown Address"
+
+	return strings.Join(parts, ", ")
 }
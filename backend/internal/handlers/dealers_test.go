@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"hackutd2025/backend/internal/models"
+)
+
+func TestGetDealersGroupsListings(t *testing.T) {
+	first := models.Listing{VIN: "A", CurrentPrice: 30000}
+	first.Dealer.CarfaxID = "d1"
+	second := models.Listing{VIN: "B", CurrentPrice: 29000}
+	second.Dealer.CarfaxID = "d1"
+	useListingProvider(t, stubProvider{response: &models.CarfaxResponse{Listings: []models.Listing{first, second}}})
+
+	rec := httptest.NewRecorder()
+	GetDealers(rec, httptest.NewRequest(http.MethodGet, "/api/dealers?zip=75007", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body)
+	}
+
+	var response models.DealersResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if response.TotalDealers != 1 || response.Dealers[0].VehicleCount != 2 || response.Dealers[0].LowestPrice != 29000 {
+		t.Errorf("response = %+v, want one dealer with 2 vehicles from 29000", response)
+	}
+}
+
+func TestGetDealersProviderFailure(t *testing.T) {
+	useListingProvider(t, stubProvider{err: errors.New("upstream down")})
+
+	rec := httptest.NewRecorder()
+	GetDealers(rec, httptest.NewRequest(http.MethodGet, "/api/dealers?zip=75007", nil))
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadGateway)
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"hackutd2025/backend/internal/auth"
+	"hackutd2025/backend/internal/logging"
+)
+
+const (
+	// IdempotencyKeyHeader names the header clients set to make a call submission safe to retry
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses replayed from an earlier request with the same key
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	maxSubmitBodyBytes      = 1 << 20
+)
+
+// replayedHeaders are the response headers stored with a submission and sent again when it is replayed
+var replayedHeaders = []string{"X-Quota-Limit", "X-Quota-Remaining"}
+
+// responseCapture passes a response through to the client while keeping a copy of its status and body
+type responseCapture struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (c *responseCapture) WriteHeader(status int) {
+	c.status = status
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *responseCapture) Write(b []byte) (int, error) {
+	c.body.Write(b)
+	return c.ResponseWriter.Write(b)
+}
+
+// submitIdempotent runs a call submission at most once per user and Idempotency-Key
+// A retry with the same key and body gets the original response; a different body gets 409
+func (h *CallHandler) submitIdempotent(w http.ResponseWriter, r *http.Request, key string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if len(key) > maxIdempotencyKeyLength || !isVisibleASCII(key) {
+		writeSubmitError(w, http.StatusBadRequest, "Idempotency-Key must be at most 255 visible ASCII characters")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSubmitBodyBytes))
+	if err != nil {
+		slog.WarnContext(r.Context(), "failed to read call submit body", logging.Err(err))
+		writeSubmitError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+	sum := sha256.Sum256(body)
+	requestHash := hex.EncodeToString(sum[:])
+
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		userID = anonymousUser
+	}
+
+	// The key is held for the length of the request, so a crashed request does not block retries for the whole TTL
+	record, err := h.store.ReserveIdempotencyKey(r.Context(), userID, key, requestHash, time.Now().Add(h.idempotencyLock))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to reserve idempotency key", logging.Err(err))
+		writeSubmitError(w, http.StatusServiceUnavailable, "Failed to check Idempotency-Key")
+		return
+	}
+
+	if record != nil {
+		switch {
+		case record.RequestHash != requestHash:
+			slog.WarnContext(r.Context(), "idempotency key reused with a different body")
+			writeSubmitError(w, http.StatusConflict, "Idempotency-Key was already used with a different request body")
+		case !record.Completed:
+			w.Header().Set("Retry-After", "1")
+			writeSubmitError(w, http.StatusConflict, "A request with this Idempotency-Key is still being processed")
+		default:
+			slog.InfoContext(r.Context(), "replaying call submission", slog.Int("status", record.StatusCode))
+			for name, value := range record.Headers {
+				w.Header().Set(name, value)
+			}
+			w.Header().Set(IdempotentReplayedHeader, "true")
+			w.WriteHeader(record.StatusCode)
+			w.Write(record.Response)
+		}
+		return
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	capture := &responseCapture{ResponseWriter: w, status: http.StatusOK}
+	h.submitCalls(capture, r)
+
+	// Record the outcome even if the client has gone away, since that is when it will retry
+	ctx := context.WithoutCancel(r.Context())
+	if isRetryableSubmitStatus(capture.status) {
+		err = h.store.ReleaseIdempotencyKey(ctx, userID, key)
+	} else {
+		headers := make(map[string]string, len(replayedHeaders))
+		for _, name := range replayedHeaders {
+			if value := capture.Header().Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+		err = h.store.CompleteIdempotencyKey(ctx, userID, key, capture.status, headers, capture.body.Bytes(), time.Now().Add(h.idempotencyTTL))
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to record idempotency key", logging.Err(err))
+	}
+}
+
+// isRetryableSubmitStatus reports whether a submission ended before any call was dialed,
+// so its key is released rather than replayed: rate limited, unavailable or cancelled
+func isRetryableSubmitStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
+
+// isVisibleASCII reports whether s is non-empty and made only of printable ASCII characters other than space
+func isVisibleASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] <= ' ' || s[i] > '~' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// writeSubmitError writes a failed CallSubmitResponse with status
+func writeSubmitError(w http.ResponseWriter, status int, message string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(CallSubmitResponse{
+		Success: false,
+		Message: message,
+	})
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"hackutd2025/backend/internal/database"
+)
+
+func TestSubmitCallsReplaysResponseWithQuotaHeaders(t *testing.T) {
+	store := database.NewMemoryCallStore()
+	h := NewCallHandler(store, CallHandlerOptions{
+		Limiter:         newTestLimiter(t, store, 5),
+		IdempotencyTTL:  time.Hour,
+		IdempotencyLock: time.Minute,
+	})
+	header := http.Header{IdempotencyKeyHeader: {"submit-1"}}
+	calls := []CallSubmitRequest{testCall}
+
+	first := postJSON(t, h.SubmitCalls, "/api/calls/submit", calls, header)
+	if first.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d (body %s)", first.Code, http.StatusAccepted, first.Body)
+	}
+
+	replay := postJSON(t, h.SubmitCalls, "/api/calls/submit", calls, header)
+	if replay.Code != http.StatusAccepted {
+		t.Fatalf("replay status = %d, want %d", replay.Code, http.StatusAccepted)
+	}
+	if replay.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("%s header missing from replay", IdempotentReplayedHeader)
+	}
+	if replay.Body.String() != first.Body.String() {
+		t.Errorf("replay body = %s, want %s", replay.Body, first.Body)
+	}
+	for _, name := range []string{"X-Quota-Limit", "X-Quota-Remaining"} {
+		if got, want := replay.Header().Get(name), first.Header().Get(name); got != want || got == "" {
+			t.Errorf("replay %s = %q, want %q", name, got, want)
+		}
+	}
+
+	// The replay did not take any more quota
+	if remaining := replay.Header().Get("X-Quota-Remaining"); remaining != "4" {
+		t.Errorf("X-Quota-Remaining = %q, want 4", remaining)
+	}
+}
+
+func TestSubmitCallsRejectsReusedKeyWithDifferentBody(t *testing.T) {
+	h := NewCallHandler(database.NewMemoryCallStore(), CallHandlerOptions{IdempotencyTTL: time.Hour, IdempotencyLock: time.Minute})
+	header := http.Header{IdempotencyKeyHeader: {"submit-1"}}
+
+	submit(t, h, []CallSubmitRequest{testCall}, header, http.StatusAccepted)
+
+	other := testCall
+	other.DealerName = "Toyota of Dallas"
+	submit(t, h, []CallSubmitRequest{other}, header, http.StatusConflict)
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"hackutd2025/backend/internal/database"
+	"hackutd2025/backend/internal/logging"
+
+	"github.com/gorilla/mux"
+)
+
+// PriceTrend summarizes how a listing's price has moved across observations
+type PriceTrend struct {
+	FirstPrice   int  `json:"first_price"`
+	LatestPrice  int  `json:"latest_price"`
+	LowestPrice  int  `json:"lowest_price"`
+	HighestPrice int  `json:"highest_price"`
+	Change       int  `json:"change"`
+	PriceDrops   int  `json:"price_drops"`
+	IsDropping   bool `json:"is_dropping"`
+}
+
+// ListingHandler serves stored listing history on top of a ListingStore
+type ListingHandler struct {
+	store database.ListingStore
+}
+
+// NewListingHandler creates the listing history handlers over store
+func NewListingHandler(store database.ListingStore) *ListingHandler {
+	return &ListingHandler{store: store}
+}
+
+// GetListingHistory handles GET /api/listings/{vin}/history
+// Returns every recorded price observation for a VIN along with a trend summary
+func (h *ListingHandler) GetListingHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vin := strings.ToUpper(strings.TrimSpace(mux.Vars(r)["vin"]))
+	if vin == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "vin is required",
+		})
+		return
+	}
+
+	snapshot, err := h.store.GetListingSnapshot(r.Context(), vin)
+	if errors.Is(err, database.ErrListingNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Listing not found",
+		})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to load listing", slog.String("vin", vin), logging.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Failed to retrieve listing",
+		})
+		return
+	}
+
+	observations, err := h.store.GetListingObservations(r.Context(), vin)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to load price history", slog.String("vin", vin), logging.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Failed to retrieve price history",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"listing":      snapshot,
+			"observations": observations,
+			"trend":        summarizePriceTrend(observations),
+		},
+	})
+}
+
+// summarizePriceTrend computes the price movement across observations, ignoring unpriced ones
+func summarizePriceTrend(observations []database.ListingObservation) PriceTrend {
+	var trend PriceTrend
+	previous := 0
+
+	for _, observation := range observations {
+		price := observation.CurrentPrice
+		if price <= 0 {
+			continue
+		}
+
+		if trend.FirstPrice == 0 {
+			trend.FirstPrice = price
+			trend.LowestPrice = price
+		}
+		if price < trend.LowestPrice {
+			trend.LowestPrice = price
+		}
+		if price > trend.HighestPrice {
+			trend.HighestPrice = price
+		}
+		if previous > 0 && price < previous {
+			trend.PriceDrops++
+		}
+
+		trend.LatestPrice = price
+		previous = price
+	}
+
+	trend.Change = trend.LatestPrice - trend.FirstPrice
+	trend.IsDropping = trend.Change < 0
+	return trend
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"hackutd2025/backend/internal/database"
+	"hackutd2025/backend/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+// serveListingHistory routes one history request through handler
+func serveListingHistory(handler *ListingHandler, vin string) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	router.HandleFunc("/api/listings/{vin}/history", handler.GetListingHistory)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/listings/"+vin+"/history", nil))
+	return rec
+}
+
+func TestGetListingHistory(t *testing.T) {
+	store := database.NewMemoryListingStore()
+	for _, price := range []int{32000, 31500, 31000} {
+		listing := models.Listing{VIN: "2T3P1RFV5RC000001", Model: "RAV4", Year: 2024, CurrentPrice: price}
+		if err := store.UpsertListings(context.Background(), []models.Listing{listing}); err != nil {
+			t.Fatalf("UpsertListings: %v", err)
+		}
+	}
+
+	rec := serveListingHistory(NewListingHandler(store), "2t3p1rfv5rc000001")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body)
+	}
+
+	var body struct {
+		Data struct {
+			Observations []database.ListingObservation `json:"observations"`
+			Trend        PriceTrend                    `json:"trend"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(body.Data.Observations) != 3 {
+		t.Errorf("observations = %d, want 3", len(body.Data.Observations))
+	}
+	if body.Data.Trend.Change != -1000 || body.Data.Trend.PriceDrops != 2 {
+		t.Errorf("trend = %+v, want change -1000 with 2 drops", body.Data.Trend)
+	}
+}
+
+func TestGetListingHistoryNotFound(t *testing.T) {
+	rec := serveListingHistory(NewListingHandler(database.NewMemoryListingStore()), "UNKNOWN")
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
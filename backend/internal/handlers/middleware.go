@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// RequestBudget bounds the total time a request may take
+// The deadline is attached to the request context, so every database call, CARFAX lookup
+// and agent dispatch made on behalf of the request is cancelled once it passes
+func RequestBudget(budget time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), budget)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
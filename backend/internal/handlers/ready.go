@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"hackutd2025/backend/internal/health"
+)
+
+// ReadyHandler serves the readiness endpoint from a health.Checker
+type ReadyHandler struct {
+	checker *health.Checker
+}
+
+// NewReadyHandler creates the readiness handler over checker
+func NewReadyHandler(checker *health.Checker) *ReadyHandler {
+	return &ReadyHandler{checker: checker}
+}
+
+// GetReady handles GET /ready
+// Returns 200 when every critical dependency is up (even if others are down) and 503 otherwise
+func (h *ReadyHandler) GetReady(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	report := h.checker.Check(r.Context())
+
+	if report.Ready() {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
@@ -1,15 +1,32 @@
 package handlers
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 
+	"hackutd2025/backend/internal/listings"
+	"hackutd2025/backend/internal/logging"
 	"hackutd2025/backend/internal/models"
 )
 
+// maxPageLimit caps the page size a client can request
+const maxPageLimit = 100
+
+// listingProvider is the inventory source used by GetSellers and SearchDealers
+var listingProvider listings.ListingProvider = listings.NewCarfaxProvider(listings.CarfaxOptions{})
+
+// SetListingProvider replaces the inventory source used by the listing handlers
+func SetListingProvider(provider listings.ListingProvider) {
+	listingProvider = provider
+}
+
 // GetSellers handles requests to get car sellers
 func GetSellers(w http.ResponseWriter, r *http.Request) {
 	// Only allow GET requests
@@ -22,109 +39,206 @@ func GetSellers(w http.ResponseWriter, r *http.Request) {
 
 	// Get query parameters
 	queryParams := r.URL.Query()
-	zip := queryParams.Get("zip")
-	radius := queryParams.Get("radius")
-	model := queryParams.Get("model")
-
-	// Validate required parameters
-	if zip == "" {
+	query, err := parseListingQuery(queryParams)
+	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "zip parameter is required"})
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	if radius == "" {
-		radius = "50" // Default radius
+	filter, err := parseListingFilter(queryParams)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: err.Error()})
+		return
 	}
 
-	if model == "" {
-		model = "RAV4"
+	sortOrder, err := listings.ParseSortOrder(queryParams.Get("sort"))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: err.Error()})
+		return
 	}
 
-	// Build CARFAX API URL
-	carfaxURL := buildCarfaxURL(zip, radius, model)
+	// limit is optional, but when given it must be in range
+	limit, err := nonNegativeIntParam(queryParams, "limit")
+	if err != nil || limit > maxPageLimit || (queryParams.Has("limit") && limit < 1) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: fmt.Sprintf("limit must be between 1 and %d", maxPageLimit)})
+		return
+	}
 
-	// Make request to CARFAX API
-	response, err := makeCarfaxRequest(carfaxURL)
+	// Fetch listings from the configured provider
+	response, err := listingProvider.Search(r.Context(), query)
 	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to fetch listings", slog.String("provider", listingProvider.Name()), logging.Err(err))
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(http.StatusBadGateway)
 		json.NewEncoder(w).Encode(models.ErrorResponse{Error: fmt.Sprintf("Failed to fetch data: %v", err)})
 		return
 	}
 
+	matched := filter.Apply(response.Listings)
+	listings.Sort(matched, sortOrder)
+
+	// Without a limit the full result set is returned, as before pagination existed
+	page, nextCursor, err := listings.Paginate(matched, limit, queryParams.Get("cursor"), searchFingerprint(queryParams))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "cursor is invalid or belongs to a different search"})
+		return
+	}
+
 	// Return the response
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(models.SellersResponse{
+		SearchArea:   response.SearchArea,
+		Listings:     page,
+		TotalFetched: len(response.Listings),
+		TotalMatched: len(matched),
+		NextCursor:   nextCursor,
+	})
 }
 
-// buildCarfaxURL constructs the CARFAX API URL with parameters
-func buildCarfaxURL(zip, radius, model string) string {
-	baseURL := "https://helix.carfax.com/search/v2/vehicles"
-	params := url.Values{}
-	params.Add("zip", zip)
-	params.Add("radius", radius)
-	params.Add("model", model)
-	params.Add("sort", "BEST")
-	params.Add("dynamicRadius", "true")
-	params.Add("make", "Toyota")
-	params.Add("vehicleCondition", "NEW")
-	params.Add("rows", "24")
-	params.Add("fetchImageLimit", "6")
-	params.Add("tpPositions", "1,2,3")
-
-	return fmt.Sprintf("%s?%s", baseURL, params.Encode())
+// searchFingerprint identifies a search by its parameters, ignoring the pagination ones
+func searchFingerprint(params url.Values) string {
+	search := url.Values{}
+	for key, values := range params {
+		if key == "limit" || key == "cursor" {
+			continue
+		}
+		search[key] = values
+	}
+
+	// Encode sorts by key, so equivalent searches produce the same fingerprint
+	sum := sha256.Sum256([]byte(search.Encode()))
+	return hex.EncodeToString(sum[:8])
 }
 
-// makeCarfaxRequest makes the HTTP request to CARFAX API
-func makeCarfaxRequest(carfaxURL string) (*models.CarfaxResponse, error) {
-	// Create HTTP client
-	client := &http.Client{}
+// parseListingQuery reads the search area and vehicle query parameters, applying defaults
+func parseListingQuery(params url.Values) (listings.Query, error) {
+	query := listings.Query{
+		Zip:    params.Get("zip"),
+		Radius: 50, // Default radius
+		Make:   params.Get("make"),
+		Model:  params.Get("model"),
+	}
 
-	// Create request
-	req, err := http.NewRequest("GET", carfaxURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Add headers
-	// Note: Not setting Accept-Encoding allows Go's http.Client to handle compression automatically
-	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64; rv:140.0) Gecko/20100101 Firefox/140.0")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
-	req.Header.Set("Referer", "https://www.carfax.com/")
-	req.Header.Set("Origin", "https://www.carfax.com")
-	req.Header.Set("Connection", "keep-alive")
-	req.Header.Set("Sec-Fetch-Dest", "empty")
-	req.Header.Set("Sec-Fetch-Mode", "cors")
-	req.Header.Set("Sec-Fetch-Site", "same-site")
-
-	// Execute request
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+	if query.Zip == "" {
+		return query, fmt.Errorf("zip parameter is required")
 	}
-	defer resp.Body.Close()
 
-	// Check status code
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("CARFAX API returned status %d: %s", resp.StatusCode, string(body))
+	if radius := params.Get("radius"); radius != "" {
+		radiusMiles, err := strconv.Atoi(radius)
+		if err != nil || radiusMiles <= 0 {
+			return query, fmt.Errorf("radius must be a positive integer")
+		}
+		query.Radius = radiusMiles
 	}
 
-	// Read response body (Go's http.Client automatically handles decompression)
-	body, err := io.ReadAll(resp.Body)
+	if query.Make == "" {
+		query.Make = listings.DefaultMake
+	}
+
+	if query.Model == "" && strings.EqualFold(query.Make, listings.DefaultMake) {
+		query.Model = "RAV4"
+	}
+
+	condition, err := listings.ParseCondition(params.Get("condition"))
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return query, err
 	}
+	query.Condition = condition
+
+	return query, nil
+}
+
+// parseListingFilter reads the listing filter query parameters
+// List parameters accept repeated values or a comma-separated list
+func parseListingFilter(params url.Values) (listings.Filter, error) {
+	var filter listings.Filter
+	var err error
+
+	filter.Trims = listParam(params, "trim")
+	filter.Colors = listParam(params, "color")
+	filter.Drivetypes = listParam(params, "drivetrain")
+	filter.Fuels = listParam(params, "fuel")
+	filter.Options = listParam(params, "options")
 
-	// Parse JSON response
-	var carfaxResponse models.CarfaxResponse
-	if err := json.Unmarshal(body, &carfaxResponse); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON response: %w", err)
+	if filter.MinPrice, err = nonNegativeIntParam(params, "min_price"); err != nil {
+		return filter, err
 	}
+	if filter.MaxPrice, err = nonNegativeIntParam(params, "max_price"); err != nil {
+		return filter, err
+	}
+	if filter.MaxPrice > 0 && filter.MinPrice > filter.MaxPrice {
+		return filter, fmt.Errorf("min_price must not be greater than max_price")
+	}
+	if filter.MinMPG, err = nonNegativeIntParam(params, "min_mpg"); err != nil {
+		return filter, err
+	}
+
+	if value := params.Get("max_distance"); value != "" {
+		filter.MaxDistance, err = strconv.ParseFloat(value, 64)
+		if err != nil || filter.MaxDistance <= 0 {
+			return filter, fmt.Errorf("max_distance must be a positive number")
+		}
+	}
+
+	return filter, nil
+}
 
-	return &carfaxResponse, nil
+// listParam collects a parameter given repeatedly and/or as a comma-separated list
+func listParam(params url.Values, name string) []string {
+	var values []string
+	for _, raw := range params[name] {
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// nonNegativeIntParam parses an optional non-negative integer parameter
+func nonNegativeIntParam(params url.Values, name string) (int, error) {
+	value := params.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer", name)
+	}
+	return parsed, nil
+}
+
+// GetCacheStats handles GET /api/cache/stats
+// Reports listing cache hit and miss counts when the provider is cached
+func GetCacheStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	cached, ok := listingProvider.(*listings.CachedProvider)
+	if !ok {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"enabled": false,
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"enabled":  true,
+		"provider": cached.Name(),
+		"stats":    cached.Stats(),
+	})
 }
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"hackutd2025/backend/internal/listings"
+	"hackutd2025/backend/internal/models"
+)
+
+// stubProvider answers every search with a fixed response or error
+type stubProvider struct {
+	response *models.CarfaxResponse
+	err      error
+}
+
+func (p stubProvider) Name() string { return "stub" }
+
+func (p stubProvider) Search(context.Context, listings.Query) (*models.CarfaxResponse, error) {
+	return p.response, p.err
+}
+
+// useListingProvider installs provider for the duration of a test
+func useListingProvider(t *testing.T, provider listings.ListingProvider) {
+	t.Helper()
+	previous := listingProvider
+	SetListingProvider(provider)
+	t.Cleanup(func() { SetListingProvider(previous) })
+}
+
+func TestGetSellersLimit(t *testing.T) {
+	useListingProvider(t, stubProvider{response: &models.CarfaxResponse{
+		Listings: []models.Listing{{VIN: "A"}, {VIN: "B"}, {VIN: "C"}},
+	}})
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"zip=75007", http.StatusOK},
+		{"zip=75007&limit=1", http.StatusOK},
+		{"zip=75007&limit=100", http.StatusOK},
+		{"zip=75007&limit=0", http.StatusBadRequest},
+		{"zip=75007&limit=101", http.StatusBadRequest},
+		{"zip=75007&limit=-1", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		GetSellers(rec, httptest.NewRequest(http.MethodGet, "/api/sellers?"+tt.query, nil))
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d (body %s)", tt.query, rec.Code, tt.want, rec.Body)
+		}
+	}
+}
+
+func TestGetSellersProviderFailure(t *testing.T) {
+	useListingProvider(t, stubProvider{err: errors.New("upstream down")})
+
+	rec := httptest.NewRecorder()
+	GetSellers(rec, httptest.NewRequest(http.MethodGet, "/api/sellers?zip=75007", nil))
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadGateway)
+	}
+}
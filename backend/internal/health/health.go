@@ -0,0 +1,149 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Status values reported for a dependency or for the service as a whole
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusReady    = "ready"
+	StatusDegraded = "degraded"
+	StatusNotReady = "not_ready"
+)
+
+// Probe checks one dependency and returns an error if it is unhealthy
+type Probe func(ctx context.Context) error
+
+// Check is a named dependency probe
+// A failing critical check makes the service not ready; a failing non-critical check only degrades it
+type Check struct {
+	Name     string
+	Critical bool
+	Probe    Probe
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Critical  bool   `json:"critical"`
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Report is the outcome of every check
+type Report struct {
+	Status    string    `json:"status"`
+	Checks    []Result  `json:"checks"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// Ready reports whether every critical check passed
+func (r Report) Ready() bool {
+	return r.Status != StatusNotReady
+}
+
+// Checker runs checks concurrently and caches the report so frequent probes do not hammer dependencies
+type Checker struct {
+	checks  []Check
+	timeout time.Duration
+	ttl     time.Duration
+
+	mu       sync.Mutex
+	report   *Report
+	inflight singleflight.Group
+}
+
+// NewChecker creates a checker; each probe is bounded by timeout and reports are reused for ttl
+func NewChecker(timeout, ttl time.Duration, checks ...Check) *Checker {
+	return &Checker{
+		checks:  checks,
+		timeout: timeout,
+		ttl:     ttl,
+	}
+}
+
+// Check returns the cached report, or runs every check if it has expired
+// Concurrent callers share a single run, which is not cancelled when any one caller goes away
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.Lock()
+	if c.report != nil && time.Since(c.report.CheckedAt) < c.ttl {
+		report := *c.report
+		c.mu.Unlock()
+		return report
+	}
+	c.mu.Unlock()
+
+	result := c.inflight.DoChan("report", func() (interface{}, error) {
+		report := c.run(context.WithoutCancel(ctx))
+
+		c.mu.Lock()
+		c.report = &report
+		c.mu.Unlock()
+
+		return report, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return Report{Status: StatusNotReady, CheckedAt: time.Now()}
+	case r := <-result:
+		return r.Val.(Report)
+	}
+}
+
+// run probes every dependency in parallel
+func (c *Checker) run(ctx context.Context) Report {
+	results := make([]Result, len(c.checks))
+
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.probe(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	status := StatusReady
+	for _, result := range results {
+		if result.Status == StatusUp {
+			continue
+		}
+		if result.Critical {
+			status = StatusNotReady
+			break
+		}
+		status = StatusDegraded
+	}
+
+	return Report{Status: status, Checks: results, CheckedAt: time.Now()}
+}
+
+// probe runs a single check under the probe timeout
+func (c *Checker) probe(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Probe(ctx)
+
+	result := Result{
+		Name:      check.Name,
+		Status:    StatusUp,
+		Critical:  check.Critical,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
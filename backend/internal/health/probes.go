@@ -0,0 +1,63 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PingProbe checks that Postgres answers a ping
+func PingProbe(pool *pgxpool.Pool) Probe {
+	return func(ctx context.Context) error {
+		return pool.Ping(ctx)
+	}
+}
+
+// HTTPStatusProbe checks that GET url answers with a 2xx status
+func HTTPStatusProbe(client *http.Client, url string) Probe {
+	return func(ctx context.Context) error {
+		status, err := get(ctx, client, url)
+		if err != nil {
+			return err
+		}
+		if status < 200 || status > 299 {
+			return fmt.Errorf("status %d", status)
+		}
+		return nil
+	}
+}
+
+// ReachableProbe checks that url answers at all without a server error
+// Useful for APIs that reject a bare request but are otherwise up
+func ReachableProbe(client *http.Client, url string) Probe {
+	return func(ctx context.Context) error {
+		status, err := get(ctx, client, url)
+		if err != nil {
+			return err
+		}
+		if status >= 500 {
+			return fmt.Errorf("status %d", status)
+		}
+		return nil
+	}
+}
+
+// get issues a GET and returns the status code, discarding the body
+func get(ctx context.Context, client *http.Client, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,253 @@
+package listings
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"hackutd2025/backend/internal/logging"
+	"hackutd2025/backend/internal/models"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// CacheOptions configures a CachedProvider
+type CacheOptions struct {
+	// TTL is how long a response is served without going upstream
+	TTL time.Duration
+	// StaleTTL is how long after TTL an expired response is still served while it is refreshed in the background
+	StaleTTL time.Duration
+	// MaxEntries bounds the number of cached queries; the least recently used entry is evicted first
+	MaxEntries int
+	// FetchTimeout bounds an upstream fetch, which is shared by every coalesced caller
+	// and so is not cancelled when any single caller goes away
+	FetchTimeout time.Duration
+}
+
+// CacheStats reports cache effectiveness counters
+type CacheStats struct {
+	Hits      uint64 `json:"hits"`
+	StaleHits uint64 `json:"staleHits"`
+	Misses    uint64 `json:"misses"`
+	Coalesced uint64 `json:"coalesced"`
+	Evictions uint64 `json:"evictions"`
+	Entries   int    `json:"entries"`
+}
+
+// cacheEntry is a cached provider response
+type cacheEntry struct {
+	key       string
+	response  *models.CarfaxResponse
+	fetchedAt time.Time
+}
+
+// CachedProvider wraps a ListingProvider with a TTL cache and request coalescing
+// Concurrent identical queries share a single upstream call
+type CachedProvider struct {
+	provider ListingProvider
+	options  CacheOptions
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	group   singleflight.Group
+	// closed is set by Wait; no background refresh starts after it, guarded by mu
+	closed bool
+
+	// refreshes tracks background refreshes so shutdown can wait for them
+	refreshes sync.WaitGroup
+
+	hits      atomic.Uint64
+	staleHits atomic.Uint64
+	misses    atomic.Uint64
+	coalesced atomic.Uint64
+	evictions atomic.Uint64
+}
+
+// NewCachedProvider wraps provider with a response cache
+func NewCachedProvider(provider ListingProvider, options CacheOptions) *CachedProvider {
+	if options.MaxEntries <= 0 {
+		options.MaxEntries = 256
+	}
+	if options.FetchTimeout <= 0 {
+		options.FetchTimeout = time.Minute
+	}
+
+	return &CachedProvider{
+		provider: provider,
+		options:  options,
+		now:      time.Now,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// Name implements ListingProvider
+func (p *CachedProvider) Name() string {
+	return "cached(" + p.provider.Name() + ")"
+}
+
+// Search implements ListingProvider
+// A caller whose ctx ends stops waiting, but the shared upstream fetch carries on for the others
+func (p *CachedProvider) Search(ctx context.Context, query Query) (*models.CarfaxResponse, error) {
+	key := cacheKey(query)
+
+	if entry, ok := p.lookup(key); ok {
+		age := p.now().Sub(entry.fetchedAt)
+		if age < p.options.TTL {
+			p.hits.Add(1)
+			return cloneResponse(entry.response), nil
+		}
+
+		if age < p.options.TTL+p.options.StaleTTL {
+			p.staleHits.Add(1)
+			p.refresh(ctx, key, query)
+			return cloneResponse(entry.response), nil
+		}
+	}
+
+	p.misses.Add(1)
+	var leader atomic.Bool
+	results := p.group.DoChan(key, func() (interface{}, error) {
+		leader.Store(true)
+		return p.fetch(ctx, key, query)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-results:
+		if result.Shared && !leader.Load() {
+			p.coalesced.Add(1)
+		}
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return cloneResponse(result.Val.(*models.CarfaxResponse)), nil
+	}
+}
+
+// Stats returns a snapshot of the cache counters
+func (p *CachedProvider) Stats() CacheStats {
+	p.mu.Lock()
+	entries := p.lru.Len()
+	p.mu.Unlock()
+
+	return CacheStats{
+		Hits:      p.hits.Load(),
+		StaleHits: p.staleHits.Load(),
+		Misses:    p.misses.Load(),
+		Coalesced: p.coalesced.Load(),
+		Evictions: p.evictions.Load(),
+		Entries:   entries,
+	}
+}
+
+// Wait stops new background refreshes and blocks until every running one has finished
+// Stale entries are still served afterwards, just no longer refreshed
+func (p *CachedProvider) Wait() {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	p.refreshes.Wait()
+}
+
+// refresh re-fetches a stale entry in the background, sharing any refresh already in flight
+func (p *CachedProvider) refresh(ctx context.Context, key string, query Query) {
+	// Add must not race with Wait, so it is only called while the cache is known to be open
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.refreshes.Add(1)
+	p.mu.Unlock()
+
+	results := p.group.DoChan(key, func() (interface{}, error) {
+		response, err := p.fetch(ctx, key, query)
+		if err != nil {
+			slog.WarnContext(ctx, "background cache refresh failed", slog.String("key", key), logging.Err(err))
+		}
+		return response, err
+	})
+
+	go func() {
+		defer p.refreshes.Done()
+		<-results
+	}()
+}
+
+// fetch queries the wrapped provider and stores a successful response
+// The fetch keeps ctx's values but not its cancellation, since other callers may be waiting on it
+func (p *CachedProvider) fetch(ctx context.Context, key string, query Query) (*models.CarfaxResponse, error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), p.options.FetchTimeout)
+	defer cancel()
+
+	response, err := p.provider.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	p.store(key, response)
+	return response, nil
+}
+
+// lookup returns the entry for key and marks it as recently used
+func (p *CachedProvider) lookup(key string) (*cacheEntry, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	element, ok := p.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	p.lru.MoveToFront(element)
+	return element.Value.(*cacheEntry), true
+}
+
+// store saves a response, evicting the least recently used entries beyond MaxEntries
+func (p *CachedProvider) store(key string, response *models.CarfaxResponse) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry := &cacheEntry{key: key, response: response, fetchedAt: p.now()}
+	if element, ok := p.entries[key]; ok {
+		element.Value = entry
+		p.lru.MoveToFront(element)
+		return
+	}
+
+	p.entries[key] = p.lru.PushFront(entry)
+	for p.lru.Len() > p.options.MaxEntries {
+		oldest := p.lru.Back()
+		p.lru.Remove(oldest)
+		delete(p.entries, oldest.Value.(*cacheEntry).key)
+		p.evictions.Add(1)
+	}
+}
+
+// cacheKey normalizes the query parameters sent to the provider
+func cacheKey(query Query) string {
+	return fmt.Sprintf("zip=%s|radius=%d|make=%s|model=%s|condition=%s",
+		strings.TrimSpace(query.Zip),
+		query.Radius,
+		strings.ToLower(strings.TrimSpace(query.Make)),
+		strings.ToLower(strings.TrimSpace(query.Model)),
+		query.Condition,
+	)
+}
+
+// cloneResponse copies a cached response so callers can reorder or filter the listings freely
+func cloneResponse(response *models.CarfaxResponse) *models.CarfaxResponse {
+	clone := *response
+	clone.Listings = append([]models.Listing(nil), response.Listings...)
+	return &clone
+}
@@ -0,0 +1,205 @@
+package listings
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"hackutd2025/backend/internal/models"
+)
+
+// countingProvider answers every search with one listing per call made so far, optionally holding each call until release is closed
+type countingProvider struct {
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (p *countingProvider) Name() string { return "counting" }
+
+func (p *countingProvider) Search(ctx context.Context, query Query) (*models.CarfaxResponse, error) {
+	n := p.calls.Add(1)
+	if p.release != nil {
+		<-p.release
+	}
+	listings := make([]models.Listing, n)
+	return &models.CarfaxResponse{Listings: listings}, nil
+}
+
+// fakeClock is a settable time source
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// newTestCache wraps provider in a cache running on a fake clock
+func newTestCache(provider ListingProvider, options CacheOptions) (*CachedProvider, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	cache := NewCachedProvider(provider, options)
+	cache.now = clock.Now
+	return cache, clock
+}
+
+// search runs a search that must succeed and returns how many listings it got
+func search(t *testing.T, cache *CachedProvider, zip string) int {
+	t.Helper()
+	response, err := cache.Search(context.Background(), Query{Zip: zip})
+	if err != nil {
+		t.Fatalf("Search(%s): %v", zip, err)
+	}
+	return len(response.Listings)
+}
+
+func TestCachedProviderTTL(t *testing.T) {
+	upstream := &countingProvider{}
+	cache, clock := newTestCache(upstream, CacheOptions{TTL: time.Minute, StaleTTL: time.Minute})
+
+	search(t, cache, "75007")
+	clock.Advance(59 * time.Second)
+	if got := search(t, cache, "75007"); got != 1 {
+		t.Errorf("within TTL got response %d, want the cached 1", got)
+	}
+
+	// Past TTL and the stale window the entry is not served at all
+	clock.Advance(2 * time.Minute)
+	if got := search(t, cache, "75007"); got != 2 {
+		t.Errorf("after expiry got response %d, want a fresh 2", got)
+	}
+
+	if calls := upstream.calls.Load(); calls != 2 {
+		t.Errorf("upstream calls = %d, want 2", calls)
+	}
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 2 || stats.StaleHits != 0 || stats.Entries != 1 {
+		t.Errorf("stats = %+v, want 1 hit, 2 misses, 1 entry", stats)
+	}
+}
+
+func TestCachedProviderStaleWhileRevalidate(t *testing.T) {
+	upstream := &countingProvider{}
+	cache, clock := newTestCache(upstream, CacheOptions{TTL: time.Minute, StaleTTL: time.Minute})
+
+	search(t, cache, "75007")
+	clock.Advance(90 * time.Second)
+
+	// The stale response is served at once while it is refreshed in the background
+	if got := search(t, cache, "75007"); got != 1 {
+		t.Errorf("stale search got response %d, want the stale 1", got)
+	}
+	cache.Wait()
+
+	if got := search(t, cache, "75007"); got != 2 {
+		t.Errorf("after refresh got response %d, want the refreshed 2", got)
+	}
+	stats := cache.Stats()
+	if stats.StaleHits != 1 || stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("stats = %+v, want 1 stale hit, 1 hit, 1 miss", stats)
+	}
+}
+
+func TestCachedProviderNoRefreshAfterWait(t *testing.T) {
+	upstream := &countingProvider{}
+	cache, clock := newTestCache(upstream, CacheOptions{TTL: time.Minute, StaleTTL: time.Minute})
+
+	search(t, cache, "75007")
+	cache.Wait()
+	clock.Advance(90 * time.Second)
+
+	// Once shutdown has waited for refreshes, stale entries are served without starting new ones
+	if got := search(t, cache, "75007"); got != 1 {
+		t.Errorf("stale search got response %d, want the stale 1", got)
+	}
+	cache.Wait()
+	if calls := upstream.calls.Load(); calls != 1 {
+		t.Errorf("upstream calls = %d, want 1", calls)
+	}
+}
+
+func TestCachedProviderCoalescesConcurrentMisses(t *testing.T) {
+	const callers = 5
+	upstream := &countingProvider{release: make(chan struct{})}
+	cache, _ := newTestCache(upstream, CacheOptions{TTL: time.Minute})
+
+	var wg sync.WaitGroup
+	results := make([]int, callers)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, err := cache.Search(context.Background(), Query{Zip: "75007"})
+			if err == nil {
+				results[i] = len(response.Listings)
+			}
+		}()
+	}
+
+	// Hold the upstream call until every caller has missed and joined it
+	for cache.Stats().Misses < callers {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(upstream.release)
+	wg.Wait()
+
+	if calls := upstream.calls.Load(); calls != 1 {
+		t.Errorf("upstream calls = %d, want 1", calls)
+	}
+	for i, got := range results {
+		if got != 1 {
+			t.Errorf("caller %d got response %d, want the shared 1", i, got)
+		}
+	}
+	if coalesced := cache.Stats().Coalesced; coalesced != callers-1 {
+		t.Errorf("coalesced = %d, want %d", coalesced, callers-1)
+	}
+}
+
+func TestCachedProviderEvictsLeastRecentlyUsed(t *testing.T) {
+	upstream := &countingProvider{}
+	cache, _ := newTestCache(upstream, CacheOptions{TTL: time.Minute, MaxEntries: 2})
+
+	search(t, cache, "A")
+	search(t, cache, "B")
+	search(t, cache, "A") // A is now more recently used than B
+	search(t, cache, "C") // evicts B
+
+	stats := cache.Stats()
+	if stats.Evictions != 1 || stats.Entries != 2 {
+		t.Fatalf("stats = %+v, want 1 eviction and 2 entries", stats)
+	}
+
+	before := upstream.calls.Load()
+	search(t, cache, "A")
+	if upstream.calls.Load() != before {
+		t.Errorf("A was refetched, want it still cached")
+	}
+	search(t, cache, "B")
+	if upstream.calls.Load() != before+1 {
+		t.Errorf("B was served from cache, want it evicted")
+	}
+}
+
+func TestCachedProviderReturnsCopies(t *testing.T) {
+	cache, _ := newTestCache(&countingProvider{}, CacheOptions{TTL: time.Minute})
+
+	first, _ := cache.Search(context.Background(), Query{Zip: "75007"})
+	first.Listings[0].VIN = "changed"
+
+	second, _ := cache.Search(context.Background(), Query{Zip: "75007"})
+	if second.Listings[0].VIN == "changed" {
+		t.Error("a caller's change to its response reached the cache")
+	}
+}
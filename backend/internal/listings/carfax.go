@@ -0,0 +1,202 @@
+package listings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"hackutd2025/backend/internal/logging"
+	"hackutd2025/backend/internal/metrics"
+	"hackutd2025/backend/internal/models"
+	"hackutd2025/backend/internal/tracing"
+)
+
+const (
+	// DefaultCarfaxBaseURL is the CARFAX vehicle search endpoint
+	DefaultCarfaxBaseURL = "https://helix.carfax.com/search/v2/vehicles"
+	// DefaultCarfaxRows is the page size requested from CARFAX
+	DefaultCarfaxRows = 24
+	// DefaultCarfaxMaxPages caps how many pages a single search aggregates
+	DefaultCarfaxMaxPages = 5
+	// DefaultCarfaxTimeout bounds a single CARFAX page request
+	DefaultCarfaxTimeout = 20 * time.Second
+)
+
+// CarfaxOptions configures a CarfaxProvider; zero values fall back to the defaults
+type CarfaxOptions struct {
+	BaseURL  string
+	Rows     int
+	MaxPages int
+	Timeout  time.Duration
+}
+
+// CarfaxProvider fetches live inventory from the CARFAX search API
+type CarfaxProvider struct {
+	BaseURL  string
+	Rows     int
+	MaxPages int
+	client   *http.Client
+}
+
+// NewCarfaxProvider creates a CARFAX provider with the given options
+func NewCarfaxProvider(options CarfaxOptions) *CarfaxProvider {
+	if options.BaseURL == "" {
+		options.BaseURL = DefaultCarfaxBaseURL
+	}
+	if options.Rows <= 0 {
+		options.Rows = DefaultCarfaxRows
+	}
+	if options.MaxPages <= 0 {
+		options.MaxPages = DefaultCarfaxMaxPages
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultCarfaxTimeout
+	}
+
+	return &CarfaxProvider{
+		BaseURL:  options.BaseURL,
+		Rows:     options.Rows,
+		MaxPages: options.MaxPages,
+		client: &http.Client{
+			Timeout:   options.Timeout,
+			Transport: logging.NewTransport(metrics.NewTransport("carfax", tracing.NewTransport(nil))),
+		},
+	}
+}
+
+// Name implements ListingProvider
+func (p *CarfaxProvider) Name() string {
+	return "carfax"
+}
+
+// Search implements ListingProvider
+// Pages through the results until CARFAX runs out of inventory or MaxPages is reached
+// A failure after the first page returns the pages fetched so far
+func (p *CarfaxProvider) Search(ctx context.Context, query Query) (*models.CarfaxResponse, error) {
+	maxPages := p.MaxPages
+	if maxPages <= 0 {
+		maxPages = 1
+	}
+
+	var aggregated *models.CarfaxResponse
+	for page := 1; page <= maxPages; page++ {
+		response, err := p.makeCarfaxRequest(ctx, p.buildCarfaxURL(query, page))
+		if err != nil {
+			if aggregated == nil || ctx.Err() != nil {
+				return nil, err
+			}
+			slog.WarnContext(ctx, "CARFAX page failed, returning earlier pages",
+				slog.Int("page", page), slog.Int("listings", len(aggregated.Listings)), logging.Err(err))
+			break
+		}
+
+		if aggregated == nil {
+			aggregated = response
+		} else {
+			aggregated.Listings = append(aggregated.Listings, response.Listings...)
+		}
+
+		lastPage := len(response.Listings) < p.rows() ||
+			(response.TotalPageCount > 0 && page >= response.TotalPageCount)
+		if lastPage {
+			break
+		}
+	}
+
+	if len(aggregated.Listings) < aggregated.TotalListingCount {
+		slog.InfoContext(ctx, "CARFAX search capped",
+			slog.Int("listings", len(aggregated.Listings)), slog.Int("total", aggregated.TotalListingCount), slog.Int("max_pages", maxPages))
+	}
+
+	normalizeAll(aggregated)
+	aggregated.Listings = DedupeByVIN(aggregated.Listings)
+	return aggregated, nil
+}
+
+// rows returns the configured page size
+func (p *CarfaxProvider) rows() int {
+	if p.Rows <= 0 {
+		return DefaultCarfaxRows
+	}
+	return p.Rows
+}
+
+// buildCarfaxURL constructs the CARFAX API URL with parameters for a single page
+func (p *CarfaxProvider) buildCarfaxURL(query Query, page int) string {
+	params := url.Values{}
+	params.Add("zip", query.Zip)
+	params.Add("radius", strconv.Itoa(query.Radius))
+	params.Add("model", query.Model)
+	params.Add("sort", "BEST")
+	params.Add("dynamicRadius", "true")
+	params.Add("make", query.Make)
+	switch query.Condition {
+	case ConditionUsed:
+		params.Add("vehicleCondition", "USED")
+	case ConditionCertified:
+		params.Add("vehicleCondition", "USED")
+		params.Add("certified", "true")
+	default:
+		params.Add("vehicleCondition", "NEW")
+	}
+	params.Add("rows", strconv.Itoa(p.rows()))
+	params.Add("page", strconv.Itoa(page))
+	params.Add("fetchImageLimit", "6")
+	params.Add("tpPositions", "1,2,3")
+
+	return fmt.Sprintf("%s?%s", p.BaseURL, params.Encode())
+}
+
+// makeCarfaxRequest makes the HTTP request to CARFAX API
+func (p *CarfaxProvider) makeCarfaxRequest(ctx context.Context, carfaxURL string) (*models.CarfaxResponse, error) {
+	// Create request
+	req, err := http.NewRequestWithContext(ctx, "GET", carfaxURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Add headers
+	// Note: Not setting Accept-Encoding allows Go's http.Client to handle compression automatically
+	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64; rv:140.0) Gecko/20100101 Firefox/140.0")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
+	req.Header.Set("Referer", "https://www.carfax.com/")
+	req.Header.Set("Origin", "https://www.carfax.com")
+	req.Header.Set("Connection", "keep-alive")
+	req.Header.Set("Sec-Fetch-Dest", "empty")
+	req.Header.Set("Sec-Fetch-Mode", "cors")
+	req.Header.Set("Sec-Fetch-Site", "same-site")
+
+	// Execute request
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check status code
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("CARFAX API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	// Read response body (Go's http.Client automatically handles decompression)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	// Parse JSON response
+	var carfaxResponse models.CarfaxResponse
+	if err := json.Unmarshal(body, &carfaxResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON response: %w", err)
+	}
+
+	return &carfaxResponse, nil
+}
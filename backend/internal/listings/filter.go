@@ -0,0 +1,112 @@
+package listings
+
+import (
+	"strings"
+
+	"hackutd2025/backend/internal/models"
+)
+
+// Filter narrows a listing set on listing fields
+// Zero values disable the corresponding criterion
+type Filter struct {
+	// Trims matches Trim, SubTrim or AtomTrim exactly (case-insensitive); any one trim is enough
+	Trims []string
+	// MinPrice and MaxPrice bound CurrentPrice, inclusive
+	MinPrice int
+	MaxPrice int
+	// Colors matches ExteriorColor by substring (case-insensitive), so "blue" matches "Blueprint"
+	Colors []string
+	// Drivetypes matches Drivetype exactly (case-insensitive), e.g. AWD, FWD, 4WD
+	Drivetypes []string
+	// Fuels matches Fuel by substring (case-insensitive), so "hybrid" matches "Gasoline Hybrid"
+	Fuels []string
+	// MaxDistance bounds DistanceToDealer in miles
+	MaxDistance float64
+	// MinMPG bounds MpgCombined from below
+	MinMPG int
+	// Options lists TopOptions that must all be present
+	Options []string
+}
+
+// IsEmpty reports whether the filter has no criteria
+func (f Filter) IsEmpty() bool {
+	return len(f.Trims) == 0 && f.MinPrice == 0 && f.MaxPrice == 0 && len(f.Colors) == 0 &&
+		len(f.Drivetypes) == 0 && len(f.Fuels) == 0 && f.MaxDistance == 0 && f.MinMPG == 0 &&
+		len(f.Options) == 0
+}
+
+// Match reports whether a listing satisfies every criterion of the filter
+func (f Filter) Match(listing models.Listing) bool {
+	if len(f.Trims) > 0 && !equalsAny(f.Trims, listing.Trim, listing.SubTrim, listing.AtomTrim) {
+		return false
+	}
+	if f.MinPrice > 0 && listing.CurrentPrice < f.MinPrice {
+		return false
+	}
+	if f.MaxPrice > 0 && (listing.CurrentPrice == 0 || listing.CurrentPrice > f.MaxPrice) {
+		return false
+	}
+	if len(f.Colors) > 0 && !containsAny(listing.ExteriorColor, f.Colors) {
+		return false
+	}
+	if len(f.Drivetypes) > 0 && !equalsAny(f.Drivetypes, listing.Drivetype) {
+		return false
+	}
+	if len(f.Fuels) > 0 && !containsAny(listing.Fuel, f.Fuels) {
+		return false
+	}
+	if f.MaxDistance > 0 && listing.DistanceToDealer > f.MaxDistance {
+		return false
+	}
+	if f.MinMPG > 0 && listing.MpgCombined < f.MinMPG {
+		return false
+	}
+	for _, option := range f.Options {
+		if !equalsAny(listing.TopOptions, option) && !equalsAny(listing.AtomTopOptions, option) {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply returns the listings that match the filter, preserving order
+func (f Filter) Apply(listings []models.Listing) []models.Listing {
+	if f.IsEmpty() {
+		return listings
+	}
+
+	matched := make([]models.Listing, 0, len(listings))
+	for _, listing := range listings {
+		if f.Match(listing) {
+			matched = append(matched, listing)
+		}
+	}
+	return matched
+}
+
+// equalsAny reports whether any candidate equals any value, ignoring case and surrounding space
+func equalsAny(candidates []string, values ...string) bool {
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		for _, candidate := range candidates {
+			if strings.EqualFold(strings.TrimSpace(candidate), value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// containsAny reports whether value contains any of the substrings, ignoring case
+func containsAny(value string, substrings []string) bool {
+	value = strings.ToLower(value)
+	for _, substring := range substrings {
+		if strings.Contains(value, strings.ToLower(strings.TrimSpace(substring))) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package listings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"hackutd2025/backend/internal/models"
+)
+
+// FixtureProvider serves listings from recorded CARFAX responses on disk
+type FixtureProvider struct {
+	responses []models.CarfaxResponse
+}
+
+// NewFixtureProvider loads recorded CarfaxResponse JSON from the given files or directories
+// Directories are scanned (non-recursively) for *.json files
+func NewFixtureProvider(paths ...string) (*FixtureProvider, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat fixture path %s: %w", path, err)
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		matches, err := filepath.Glob(filepath.Join(path, "*.json"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list fixtures in %s: %w", path, err)
+		}
+		files = append(files, matches...)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no fixture files found in %v", paths)
+	}
+
+	provider := &FixtureProvider{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture %s: %w", file, err)
+		}
+
+		var response models.CarfaxResponse
+		if err := json.Unmarshal(data, &response); err != nil {
+			return nil, fmt.Errorf("failed to parse fixture %s: %w", file, err)
+		}
+
+		normalizeAll(&response)
+		provider.responses = append(provider.responses, response)
+	}
+
+	return provider, nil
+}
+
+// Name implements ListingProvider
+func (p *FixtureProvider) Name() string {
+	return "fixture"
+}
+
+// Search implements ListingProvider
+// Listings are matched on make, model, condition and, when the recording has a distance, on radius
+func (p *FixtureProvider) Search(ctx context.Context, query Query) (*models.CarfaxResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	result := &models.CarfaxResponse{
+		Listings: []models.Listing{},
+	}
+
+	for _, response := range p.responses {
+		if result.SearchArea.Zip == "" {
+			result.SearchArea = response.SearchArea
+		}
+
+		for _, listing := range response.Listings {
+			if query.Make != "" && !strings.EqualFold(listing.Make, query.Make) {
+				continue
+			}
+			if query.Model != "" && !strings.EqualFold(listing.Model, query.Model) {
+				continue
+			}
+			if query.Condition != "" && !query.Condition.Matches(listing.VehicleCondition) {
+				continue
+			}
+			if query.Radius > 0 && listing.DistanceToDealer > float64(query.Radius) {
+				continue
+			}
+			result.Listings = append(result.Listings, listing)
+		}
+	}
+
+	// Report the search area that was asked for rather than the recorded one
+	result.SearchArea.Zip = query.Zip
+	result.SearchArea.Radius = query.Radius
+
+	return result, nil
+}
@@ -0,0 +1,117 @@
+package listings
+
+import (
+	"sort"
+
+	"hackutd2025/backend/internal/models"
+)
+
+// GroupByDealer collapses listings into one summary per dealer, keyed on Dealer.CarfaxID
+// Dealers are ordered by lowest price, then distance, then CARFAX ID
+func GroupByDealer(listings []models.Listing) []models.DealerSummary {
+	groups := make(map[string][]models.Listing)
+	var keys []string
+
+	for _, listing := range listings {
+		key := dealerKey(listing.Dealer)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], listing)
+	}
+
+	summaries := make([]models.DealerSummary, 0, len(keys))
+	for _, key := range keys {
+		summaries = append(summaries, summarizeDealer(groups[key]))
+	}
+
+	sort.SliceStable(summaries, func(i, j int) bool {
+		a, b := summaries[i], summaries[j]
+		if (a.LowestPrice > 0) != (b.LowestPrice > 0) {
+			return a.LowestPrice > 0
+		}
+		if a.LowestPrice != b.LowestPrice {
+			return a.LowestPrice < b.LowestPrice
+		}
+		if a.Distance != b.Distance {
+			return a.Distance < b.Distance
+		}
+		return a.CarfaxID < b.CarfaxID
+	})
+
+	return summaries
+}
+
+// dealerKey identifies a dealer, falling back to name and phone when CARFAX has no ID for it
+func dealerKey(dealer models.Dealer) string {
+	if dealer.CarfaxID != "" {
+		return dealer.CarfaxID
+	}
+	return dealer.Name + "|" + dealer.Phone
+}
+
+// summarizeDealer builds the summary for one dealer's listings
+func summarizeDealer(listings []models.Listing) models.DealerSummary {
+	dealer := listings[0].Dealer
+	summary := models.DealerSummary{
+		CarfaxID:     dealer.CarfaxID,
+		Name:         dealer.Name,
+		Phone:        dealer.Phone,
+		Address:      dealer.Address,
+		City:         dealer.City,
+		State:        dealer.State,
+		Zip:          dealer.Zip,
+		Rating:       dealer.DealerAverageRating,
+		ReviewCount:  dealer.DealerReviewCount,
+		Distance:     listings[0].DistanceToDealer,
+		VehicleCount: len(listings),
+		VINs:         make([]string, 0, len(listings)),
+	}
+
+	prices := make([]int, 0, len(listings))
+	var cheapest *models.Listing
+	for i := range listings {
+		listing := &listings[i]
+		summary.VINs = append(summary.VINs, listing.VIN)
+
+		if listing.DistanceToDealer < summary.Distance {
+			summary.Distance = listing.DistanceToDealer
+		}
+
+		if listing.CurrentPrice <= 0 {
+			continue
+		}
+		prices = append(prices, listing.CurrentPrice)
+		if cheapest == nil || listing.CurrentPrice < cheapest.CurrentPrice {
+			cheapest = listing
+		}
+	}
+
+	if cheapest != nil {
+		summary.LowestPrice = cheapest.CurrentPrice
+		summary.MedianPrice = median(prices)
+		summary.BestListing = &models.DealerListingSummary{
+			VIN:          cheapest.VIN,
+			Year:         cheapest.Year,
+			Make:         cheapest.Make,
+			Model:        cheapest.Model,
+			Trim:         cheapest.Trim,
+			Msrp:         cheapest.Msrp,
+			CurrentPrice: cheapest.CurrentPrice,
+		}
+	}
+
+	return summary
+}
+
+// median returns the median of a non-empty set of prices, averaging the middle pair for even counts
+func median(prices []int) int {
+	sorted := append([]int(nil), prices...)
+	sort.Ints(sorted)
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[middle]
+	}
+	return (sorted[middle-1] + sorted[middle]) / 2
+}
@@ -0,0 +1,107 @@
+package listings
+
+import (
+	"testing"
+
+	"hackutd2025/backend/internal/models"
+)
+
+// dealerListing is a listing of vin at a dealer
+func dealerListing(carfaxID, vin string, price int, distance float64) models.Listing {
+	listing := models.Listing{VIN: vin, CurrentPrice: price, DistanceToDealer: distance}
+	listing.Dealer.CarfaxID = carfaxID
+	listing.Dealer.Name = "Dealer " + carfaxID
+	return listing
+}
+
+func TestGroupByDealer(t *testing.T) {
+	summaries := GroupByDealer([]models.Listing{
+		dealerListing("d1", "A", 30000, 8),
+		dealerListing("d2", "B", 25000, 20),
+		dealerListing("d1", "C", 28000, 6),
+		dealerListing("d1", "D", 35000, 8),
+		dealerListing("d1", "E", 0, 8),
+		dealerListing("d3", "F", 0, 1),
+		dealerListing("d2", "G", 27000, 20),
+	})
+
+	// d2 has the lowest price; d3 has no priced listing and goes last
+	if len(summaries) != 3 {
+		t.Fatalf("got %d summaries, want 3", len(summaries))
+	}
+	tests := []struct {
+		carfaxID string
+		count    int
+		lowest   int
+		median   int
+		distance float64
+		best     string
+	}{
+		// Median of 25000 and 27000 is their average
+		{"d2", 2, 25000, 26000, 20, "B"},
+		// Median of 28000, 30000 and 35000; the unpriced listing is counted but not priced
+		{"d1", 4, 28000, 30000, 6, "C"},
+		{"d3", 1, 0, 0, 1, ""},
+	}
+	for i, tt := range tests {
+		got := summaries[i]
+		if got.CarfaxID != tt.carfaxID || got.VehicleCount != tt.count || got.LowestPrice != tt.lowest ||
+			got.MedianPrice != tt.median || got.Distance != tt.distance {
+			t.Errorf("summary %d = %s: %d vehicles, lowest %d, median %d, distance %v; want %s: %d, %d, %d, %v",
+				i, got.CarfaxID, got.VehicleCount, got.LowestPrice, got.MedianPrice, got.Distance,
+				tt.carfaxID, tt.count, tt.lowest, tt.median, tt.distance)
+		}
+		switch {
+		case tt.best == "" && got.BestListing != nil:
+			t.Errorf("%s best listing = %s, want none", tt.carfaxID, got.BestListing.VIN)
+		case tt.best != "" && (got.BestListing == nil || got.BestListing.VIN != tt.best):
+			t.Errorf("%s best listing = %v, want %s", tt.carfaxID, got.BestListing, tt.best)
+		}
+	}
+}
+
+func TestGroupByDealerWithoutCarfaxID(t *testing.T) {
+	first := dealerListing("", "A", 20000, 3)
+	first.Dealer.Name, first.Dealer.Phone = "Lot One", "555-0100"
+	second := dealerListing("", "B", 21000, 3)
+	second.Dealer.Name, second.Dealer.Phone = "Lot One", "555-0100"
+	other := dealerListing("", "C", 22000, 3)
+	other.Dealer.Name, other.Dealer.Phone = "Lot Two", "555-0200"
+
+	summaries := GroupByDealer([]models.Listing{first, second, other})
+	if len(summaries) != 2 || summaries[0].VehicleCount != 2 || summaries[1].VehicleCount != 1 {
+		t.Fatalf("summaries = %+v, want Lot One with 2 vehicles then Lot Two with 1", summaries)
+	}
+}
+
+func TestGroupByDealerOrdersTiesByDistanceThenID(t *testing.T) {
+	summaries := GroupByDealer([]models.Listing{
+		dealerListing("d3", "A", 20000, 9),
+		dealerListing("d2", "B", 20000, 4),
+		dealerListing("d1", "C", 20000, 9),
+	})
+
+	want := []string{"d2", "d1", "d3"}
+	for i, summary := range summaries {
+		if summary.CarfaxID != want[i] {
+			t.Errorf("dealer %d = %s, want %s", i, summary.CarfaxID, want[i])
+		}
+	}
+}
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		prices []int
+		want   int
+	}{
+		{[]int{5}, 5},
+		{[]int{9, 1, 5}, 5},
+		{[]int{4, 1, 3, 2}, 2},
+		{[]int{10, 20}, 15},
+	}
+	for _, tt := range tests {
+		if got := median(tt.prices); got != tt.want {
+			t.Errorf("median(%v) = %d, want %d", tt.prices, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package listings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"hackutd2025/backend/internal/logging"
+	"hackutd2025/backend/internal/models"
+)
+
+// MultiProvider merges the results of several providers, deduplicating by VIN
+// Providers earlier in the list win when the same VIN is returned more than once
+type MultiProvider struct {
+	providers []ListingProvider
+}
+
+// NewMultiProvider combines the given providers into one
+func NewMultiProvider(providers ...ListingProvider) *MultiProvider {
+	return &MultiProvider{providers: providers}
+}
+
+// Name implements ListingProvider
+func (p *MultiProvider) Name() string {
+	names := make([]string, len(p.providers))
+	for i, provider := range p.providers {
+		names[i] = provider.Name()
+	}
+	return "multi(" + strings.Join(names, ",") + ")"
+}
+
+// Search implements ListingProvider
+// A failing provider is skipped as long as at least one other provider succeeds
+func (p *MultiProvider) Search(ctx context.Context, query Query) (*models.CarfaxResponse, error) {
+	responses := make([]*models.CarfaxResponse, len(p.providers))
+	errs := make([]error, len(p.providers))
+
+	var wg sync.WaitGroup
+	for i, provider := range p.providers {
+		wg.Add(1)
+		go func(i int, provider ListingProvider) {
+			defer wg.Done()
+			responses[i], errs[i] = provider.Search(ctx, query)
+		}(i, provider)
+	}
+	wg.Wait()
+
+	merged := &models.CarfaxResponse{
+		Listings: []models.Listing{},
+	}
+	var failures []error
+	for i, response := range responses {
+		if errs[i] != nil {
+			slog.WarnContext(ctx, "listing provider failed", slog.String("provider", p.providers[i].Name()), logging.Err(errs[i]))
+			failures = append(failures, fmt.Errorf("%s: %w", p.providers[i].Name(), errs[i]))
+			continue
+		}
+
+		if merged.SearchArea.Zip == "" {
+			merged.SearchArea = response.SearchArea
+		}
+		merged.Listings = append(merged.Listings, response.Listings...)
+	}
+
+	if len(failures) == len(p.providers) && len(p.providers) > 0 {
+		return nil, errors.Join(failures...)
+	}
+
+	merged.Listings = DedupeByVIN(merged.Listings)
+	return merged, nil
+}
+
+// DedupeByVIN removes repeated VINs, keeping the first occurrence
+// Listings without a VIN are kept as-is since they cannot be matched
+func DedupeByVIN(listings []models.Listing) []models.Listing {
+	seen := make(map[string]bool, len(listings))
+	deduped := make([]models.Listing, 0, len(listings))
+
+	for _, listing := range listings {
+		if listing.VIN != "" {
+			if seen[listing.VIN] {
+				continue
+			}
+			seen[listing.VIN] = true
+		}
+		deduped = append(deduped, listing)
+	}
+
+	return deduped
+}
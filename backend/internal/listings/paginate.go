@@ -0,0 +1,100 @@
+package listings
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"hackutd2025/backend/internal/models"
+)
+
+// ErrInvalidCursor is returned when a cursor cannot be decoded or belongs to a different search
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// pageCursor is the decoded form of an opaque pagination cursor
+type pageCursor struct {
+	// Offset is the index of the first listing on the next page
+	Offset int `json:"o"`
+	// LastVIN is the VIN of the last listing already returned
+	LastVIN string `json:"v,omitempty"`
+	// Search fingerprints the query and filters the cursor was issued for
+	Search string `json:"s"`
+}
+
+// Paginate returns one page of listings starting at cursor and the cursor for the next page
+// An empty cursor starts at the beginning; an empty next cursor means there are no more pages
+// search fingerprints the query so a cursor cannot be replayed against a different search
+func Paginate(listings []models.Listing, limit int, cursor, search string) ([]models.Listing, string, error) {
+	start := 0
+	if cursor != "" {
+		decoded, err := decodeCursor(cursor)
+		if err != nil || decoded.Search != search {
+			return nil, "", ErrInvalidCursor
+		}
+		start = resumeOffset(listings, decoded)
+	}
+
+	if start >= len(listings) {
+		return []models.Listing{}, "", nil
+	}
+
+	end := start + limit
+	if limit <= 0 || end > len(listings) {
+		end = len(listings)
+	}
+
+	next := ""
+	if end < len(listings) {
+		next = encodeCursor(pageCursor{
+			Offset:  end,
+			LastVIN: listings[end-1].VIN,
+			Search:  search,
+		})
+	}
+
+	return listings[start:end], next, nil
+}
+
+// resumeOffset finds where the next page starts
+// Resuming after the last VIN keeps pages stable when listings are added or removed upstream
+func resumeOffset(listings []models.Listing, cursor pageCursor) int {
+	if cursor.LastVIN == "" {
+		return cursor.Offset
+	}
+
+	if cursor.Offset > 0 && cursor.Offset <= len(listings) && listings[cursor.Offset-1].VIN == cursor.LastVIN {
+		return cursor.Offset
+	}
+
+	for i, listing := range listings {
+		if listing.VIN == cursor.LastVIN {
+			return i + 1
+		}
+	}
+
+	return cursor.Offset
+}
+
+// encodeCursor serializes a cursor into an opaque URL-safe string
+func encodeCursor(cursor pageCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a cursor produced by encodeCursor
+func decodeCursor(cursor string) (pageCursor, error) {
+	var decoded pageCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return decoded, err
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return decoded, err
+	}
+	if decoded.Offset < 0 {
+		return decoded, ErrInvalidCursor
+	}
+
+	return decoded, nil
+}
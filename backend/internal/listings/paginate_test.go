@@ -0,0 +1,126 @@
+package listings
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"hackutd2025/backend/internal/models"
+)
+
+// vinListings returns listings with the given VINs, in order
+func vinListings(vins ...string) []models.Listing {
+	listings := make([]models.Listing, len(vins))
+	for i, vin := range vins {
+		listings[i].VIN = vin
+	}
+	return listings
+}
+
+// vins returns the VINs of listings, in order
+func vins(listings []models.Listing) []string {
+	out := make([]string, len(listings))
+	for i, listing := range listings {
+		out[i] = listing.VIN
+	}
+	return out
+}
+
+func equalVINs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPaginateWalksAllPages(t *testing.T) {
+	listings := vinListings("A", "B", "C", "D", "E")
+
+	var pages [][]string
+	cursor := ""
+	for {
+		page, next, err := Paginate(listings, 2, cursor, "search-1")
+		if err != nil {
+			t.Fatalf("Paginate(cursor %q): %v", cursor, err)
+		}
+		pages = append(pages, vins(page))
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	want := [][]string{{"A", "B"}, {"C", "D"}, {"E"}}
+	if len(pages) != len(want) {
+		t.Fatalf("pages = %v, want %v", pages, want)
+	}
+	for i := range want {
+		if !equalVINs(pages[i], want[i]) {
+			t.Errorf("page %d = %v, want %v", i, pages[i], want[i])
+		}
+	}
+}
+
+func TestPaginateResumesAfterLastVIN(t *testing.T) {
+	_, next, err := Paginate(vinListings("A", "B", "C", "D"), 2, "", "search-1")
+	if err != nil {
+		t.Fatalf("Paginate: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		listings []models.Listing
+		want     []string
+	}{
+		{"unchanged", vinListings("A", "B", "C", "D"), []string{"C", "D"}},
+		{"listing added before the cursor", vinListings("X", "A", "B", "C", "D"), []string{"C", "D"}},
+		{"listing removed before the cursor", vinListings("B", "C", "D"), []string{"C", "D"}},
+		{"last VIN gone", vinListings("A", "C", "D", "E"), []string{"D", "E"}},
+	}
+	for _, tt := range tests {
+		page, _, err := Paginate(tt.listings, 2, next, "search-1")
+		if err != nil {
+			t.Fatalf("%s: Paginate: %v", tt.name, err)
+		}
+		if got := vins(page); !equalVINs(got, tt.want) {
+			t.Errorf("%s: page = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPaginateRejectsInvalidCursors(t *testing.T) {
+	listings := vinListings("A", "B", "C")
+	_, next, err := Paginate(listings, 1, "", "search-1")
+	if err != nil {
+		t.Fatalf("Paginate: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+		search string
+	}{
+		{"different search", next, "search-2"},
+		{"not base64", "!!!", "search-1"},
+		{"not JSON", base64.RawURLEncoding.EncodeToString([]byte("not json")), "search-1"},
+		{"negative offset", encodeCursor(pageCursor{Offset: -1, Search: "search-1"}), "search-1"},
+	}
+	for _, tt := range tests {
+		if _, _, err := Paginate(listings, 1, tt.cursor, tt.search); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, ErrInvalidCursor)
+		}
+	}
+}
+
+func TestPaginatePastTheEnd(t *testing.T) {
+	cursor := encodeCursor(pageCursor{Offset: 10, Search: "search-1"})
+	page, next, err := Paginate(vinListings("A"), 2, cursor, "search-1")
+	if err != nil || len(page) != 0 || next != "" {
+		t.Errorf("Paginate = %v, %q, %v; want an empty last page", vins(page), next, err)
+	}
+}
@@ -0,0 +1,104 @@
+package listings
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"hackutd2025/backend/internal/models"
+)
+
+// Condition is the vehicle condition a search is restricted to
+type Condition string
+
+const (
+	// ConditionNew matches new vehicles
+	ConditionNew Condition = "new"
+	// ConditionUsed matches used vehicles, including certified pre-owned
+	ConditionUsed Condition = "used"
+	// ConditionCertified matches certified pre-owned vehicles only
+	ConditionCertified Condition = "certified"
+)
+
+// DefaultMake is the make searched when none is given
+const DefaultMake = "Toyota"
+
+// ParseCondition validates a condition value; an empty value means ConditionNew
+func ParseCondition(value string) (Condition, error) {
+	switch Condition(strings.ToLower(strings.TrimSpace(value))) {
+	case "", ConditionNew:
+		return ConditionNew, nil
+	case ConditionUsed:
+		return ConditionUsed, nil
+	case ConditionCertified:
+		return ConditionCertified, nil
+	default:
+		return "", fmt.Errorf("unsupported condition %q (expected new, used or certified)", value)
+	}
+}
+
+// Matches reports whether a listing's VehicleCondition satisfies the condition
+// Listings that do not report a condition are assumed to match
+func (c Condition) Matches(vehicleCondition string) bool {
+	vehicleCondition = strings.ToLower(strings.TrimSpace(vehicleCondition))
+	if vehicleCondition == "" {
+		return true
+	}
+
+	switch c {
+	case ConditionUsed:
+		return vehicleCondition != string(ConditionNew)
+	case ConditionCertified:
+		return strings.Contains(vehicleCondition, "certified") || strings.Contains(vehicleCondition, "cpo")
+	default:
+		return vehicleCondition == string(ConditionNew)
+	}
+}
+
+// Query describes a vehicle inventory search
+type Query struct {
+	Zip       string
+	Radius    int
+	Make      string
+	Model     string
+	Condition Condition
+}
+
+// ListingProvider fetches vehicle listings from a single inventory source
+type ListingProvider interface {
+	// Name identifies the source in logs and errors
+	Name() string
+	// Search returns the normalized listings matching the query
+	// Implementations must stop work and return when ctx is done
+	Search(ctx context.Context, query Query) (*models.CarfaxResponse, error)
+}
+
+// Normalize cleans up a listing so that listings from every source look alike
+func Normalize(listing models.Listing) models.Listing {
+	listing.VIN = strings.ToUpper(strings.TrimSpace(listing.VIN))
+	listing.Make = strings.TrimSpace(listing.Make)
+	listing.Model = strings.TrimSpace(listing.Model)
+	listing.Trim = strings.TrimSpace(listing.Trim)
+	listing.SubTrim = strings.TrimSpace(listing.SubTrim)
+	listing.Dealer.Name = strings.TrimSpace(listing.Dealer.Name)
+	listing.Dealer.Phone = strings.TrimSpace(listing.Dealer.Phone)
+
+	// CARFAX omits the combined figure on some listings
+	if listing.MpgCombined == 0 && listing.MpgCity > 0 && listing.MpgHighway > 0 {
+		listing.MpgCombined = (listing.MpgCity + listing.MpgHighway) / 2
+	}
+
+	// Fall back to the list price when no current price is published
+	if listing.CurrentPrice == 0 {
+		listing.CurrentPrice = listing.ListPrice
+	}
+
+	return listing
+}
+
+// normalizeAll normalizes every listing in a response in place
+func normalizeAll(response *models.CarfaxResponse) {
+	for i := range response.Listings {
+		response.Listings[i] = Normalize(response.Listings[i])
+	}
+}
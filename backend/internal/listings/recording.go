@@ -0,0 +1,54 @@
+package listings
+
+import (
+	"context"
+	"sync"
+
+	"hackutd2025/backend/internal/models"
+)
+
+// RecordingProvider hands every successful search result to a recorder in the background
+// Wrap the upstream provider, beneath any cache, so that only real fetches are recorded
+// The recorder runs after the request has returned, so it is not given the request context
+type RecordingProvider struct {
+	provider ListingProvider
+	record   func([]models.Listing)
+	wg       sync.WaitGroup
+}
+
+// NewRecordingProvider wraps provider so that its results are passed to record
+func NewRecordingProvider(provider ListingProvider, record func([]models.Listing)) *RecordingProvider {
+	return &RecordingProvider{
+		provider: provider,
+		record:   record,
+	}
+}
+
+// Name implements ListingProvider
+func (p *RecordingProvider) Name() string {
+	return p.provider.Name()
+}
+
+// Search implements ListingProvider
+func (p *RecordingProvider) Search(ctx context.Context, query Query) (*models.CarfaxResponse, error) {
+	response, err := p.provider.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(response.Listings) > 0 {
+		snapshot := append([]models.Listing(nil), response.Listings...)
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.record(snapshot)
+		}()
+	}
+
+	return response, nil
+}
+
+// Wait blocks until every in-flight recording has finished
+func (p *RecordingProvider) Wait() {
+	p.wg.Wait()
+}
@@ -0,0 +1,132 @@
+package listings
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"hackutd2025/backend/internal/models"
+)
+
+// SortOrder selects how a listing set is ordered
+type SortOrder string
+
+const (
+	// SortBest keeps the provider's relevance order
+	SortBest SortOrder = "best"
+	// SortPriceAsc orders by CurrentPrice, cheapest first
+	SortPriceAsc SortOrder = "price_asc"
+	// SortPriceDesc orders by CurrentPrice, most expensive first
+	SortPriceDesc SortOrder = "price_desc"
+	// SortDiscount orders by Msrp minus CurrentPrice, biggest discount first
+	SortDiscount SortOrder = "discount"
+	// SortDistance orders by DistanceToDealer, closest first
+	SortDistance SortOrder = "distance"
+	// SortRating orders by DealerAverageRating, best rated first
+	SortRating SortOrder = "rating"
+	// SortDaysOnLot orders by FirstSeen, longest on the lot first
+	SortDaysOnLot SortOrder = "days_on_lot"
+	// SortMPG orders by MpgCombined, most efficient first
+	SortMPG SortOrder = "mpg"
+)
+
+// sortOrders lists every supported order
+var sortOrders = []SortOrder{
+	SortBest, SortPriceAsc, SortPriceDesc, SortDiscount, SortDistance, SortRating, SortDaysOnLot, SortMPG,
+}
+
+// firstSeenLayouts are the date formats CARFAX uses for FirstSeen
+var firstSeenLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"}
+
+// ParseSortOrder validates a sort parameter; an empty value means SortBest
+func ParseSortOrder(value string) (SortOrder, error) {
+	if value == "" {
+		return SortBest, nil
+	}
+
+	for _, order := range sortOrders {
+		if SortOrder(value) == order {
+			return order, nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported sort %q (expected one of %v)", value, sortOrders)
+}
+
+// Sort orders listings in place
+// Listings missing the sort field go last, and ties are broken by VIN so the order is deterministic
+func Sort(listings []models.Listing, order SortOrder) {
+	if order == SortBest || order == "" {
+		return
+	}
+
+	key := sortKey(order)
+	sort.SliceStable(listings, func(i, j int) bool {
+		ki, okI := key(listings[i])
+		kj, okJ := key(listings[j])
+
+		switch {
+		case okI != okJ:
+			return okI
+		case okI && ki != kj:
+			return ki < kj
+		default:
+			return listings[i].VIN < listings[j].VIN
+		}
+	})
+}
+
+// sortKey returns an ascending key for the order and whether the listing has a value for it
+// Descending orders negate the key
+func sortKey(order SortOrder) func(models.Listing) (float64, bool) {
+	switch order {
+	case SortPriceAsc:
+		return func(l models.Listing) (float64, bool) {
+			return float64(l.CurrentPrice), l.CurrentPrice > 0
+		}
+	case SortPriceDesc:
+		return func(l models.Listing) (float64, bool) {
+			return -float64(l.CurrentPrice), l.CurrentPrice > 0
+		}
+	case SortDiscount:
+		return func(l models.Listing) (float64, bool) {
+			return -float64(l.Msrp - l.CurrentPrice), l.Msrp > 0 && l.CurrentPrice > 0
+		}
+	case SortDistance:
+		return func(l models.Listing) (float64, bool) {
+			return l.DistanceToDealer, true
+		}
+	case SortRating:
+		return func(l models.Listing) (float64, bool) {
+			return -l.Dealer.DealerAverageRating, l.Dealer.DealerAverageRating > 0
+		}
+	case SortDaysOnLot:
+		return func(l models.Listing) (float64, bool) {
+			firstSeen, ok := parseFirstSeen(l.FirstSeen)
+			return float64(firstSeen.Unix()), ok
+		}
+	case SortMPG:
+		return func(l models.Listing) (float64, bool) {
+			return -float64(l.MpgCombined), l.MpgCombined > 0
+		}
+	default:
+		return func(models.Listing) (float64, bool) {
+			return 0, false
+		}
+	}
+}
+
+// parseFirstSeen parses a listing's FirstSeen date
+func parseFirstSeen(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	for _, layout := range firstSeenLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, true
+		}
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,55 @@
+package listings
+
+import (
+	"testing"
+
+	"hackutd2025/backend/internal/models"
+)
+
+func TestSort(t *testing.T) {
+	listings := []models.Listing{
+		{VIN: "D", CurrentPrice: 30000, Msrp: 32000, DistanceToDealer: 5, MpgCombined: 30, FirstSeen: "2025-03-01"},
+		{VIN: "B", CurrentPrice: 28000, Msrp: 31000, DistanceToDealer: 12, MpgCombined: 40, FirstSeen: "2025-01-15T08:00:00"},
+		{VIN: "C", CurrentPrice: 30000, Msrp: 33000, DistanceToDealer: 5, FirstSeen: "2024-12-01T00:00:00Z"},
+		{VIN: "A"},
+	}
+	listings[0].Dealer.DealerAverageRating = 4.5
+	listings[1].Dealer.DealerAverageRating = 4.9
+	listings[2].Dealer.DealerAverageRating = 4.5
+
+	tests := []struct {
+		order SortOrder
+		want  []string
+	}{
+		// The provider's order is kept
+		{SortBest, []string{"D", "B", "C", "A"}},
+		// Equal prices fall back to VIN; A has no price and goes last
+		{SortPriceAsc, []string{"B", "C", "D", "A"}},
+		{SortPriceDesc, []string{"C", "D", "B", "A"}},
+		{SortDiscount, []string{"B", "C", "D", "A"}},
+		// Distance is always present, so A's zero distance sorts first
+		{SortDistance, []string{"A", "C", "D", "B"}},
+		{SortRating, []string{"B", "C", "D", "A"}},
+		{SortDaysOnLot, []string{"C", "B", "D", "A"}},
+		{SortMPG, []string{"B", "D", "A", "C"}},
+	}
+	for _, tt := range tests {
+		sorted := append([]models.Listing(nil), listings...)
+		Sort(sorted, tt.order)
+		if got := vins(sorted); !equalVINs(got, tt.want) {
+			t.Errorf("%s: order = %v, want %v", tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestParseSortOrder(t *testing.T) {
+	if order, err := ParseSortOrder(""); err != nil || order != SortBest {
+		t.Errorf("ParseSortOrder(\"\") = %q, %v; want %q", order, err, SortBest)
+	}
+	if order, err := ParseSortOrder("price_desc"); err != nil || order != SortPriceDesc {
+		t.Errorf("ParseSortOrder(price_desc) = %q, %v; want %q", order, err, SortPriceDesc)
+	}
+	if _, err := ParseSortOrder("cheapest"); err == nil {
+		t.Error("ParseSortOrder(cheapest) succeeded, want an error")
+	}
+}
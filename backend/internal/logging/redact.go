@@ -0,0 +1,32 @@
+package logging
+
+import (
+	"log/slog"
+	"unicode"
+)
+
+// Phone is the attribute for a phone number, masked to its last four digits
+func Phone(key, phone string) slog.Attr {
+	return slog.String(key, MaskPhone(phone))
+}
+
+// MaskPhone hides every digit of a phone number except the last four
+func MaskPhone(phone string) string {
+	var digits []rune
+	for _, r := range phone {
+		if unicode.IsDigit(r) {
+			digits = append(digits, r)
+		}
+	}
+
+	if len(digits) <= 4 {
+		return "***"
+	}
+	return "***" + string(digits[len(digits)-4:])
+}
+
+// Remarks is the attribute for free-form call remarks, which may contain names and numbers
+// Only the length is logged
+func Remarks(key, remarks string) slog.Attr {
+	return slog.Group(key, slog.Bool("present", remarks != ""), slog.Int("length", len(remarks)))
+}
@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"hackutd2025/backend/internal/logging"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PoolCollector exports pgxpool statistics at scrape time
+type PoolCollector struct {
+	pool *pgxpool.Pool
+
+	acquiredConns        *prometheus.Desc
+	idleConns            *prometheus.Desc
+	totalConns           *prometheus.Desc
+	maxConns             *prometheus.Desc
+	acquireCount         *prometheus.Desc
+	acquireDuration      *prometheus.Desc
+	emptyAcquireCount    *prometheus.Desc
+	emptyAcquireDuration *prometheus.Desc
+	canceledAcquireCount *prometheus.Desc
+}
+
+// NewPoolCollector creates a collector for pool
+func NewPoolCollector(pool *pgxpool.Pool) *PoolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+
+	return &PoolCollector{
+		pool:                 pool,
+		acquiredConns:        desc("acquired_conns", "Connections currently checked out of the pool."),
+		idleConns:            desc("idle_conns", "Idle connections in the pool."),
+		totalConns:           desc("total_conns", "Open connections in the pool."),
+		maxConns:             desc("max_conns", "Maximum size of the pool."),
+		acquireCount:         desc("acquires_total", "Successful connection acquires."),
+		acquireDuration:      desc("acquire_duration_seconds_total", "Total time spent acquiring connections."),
+		emptyAcquireCount:    desc("empty_acquires_total", "Acquires that had to wait because the pool had no idle connection."),
+		emptyAcquireDuration: desc("empty_acquire_wait_seconds_total", "Total time spent waiting for a connection when the pool was empty."),
+		canceledAcquireCount: desc("canceled_acquires_total", "Acquires cancelled by their context."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.totalConns
+	ch <- c.maxConns
+	ch <- c.acquireCount
+	ch <- c.acquireDuration
+	ch <- c.emptyAcquireCount
+	ch <- c.emptyAcquireDuration
+	ch <- c.canceledAcquireCount
+}
+
+// Collect implements prometheus.Collector
+func (c *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stats.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stats.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stats.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stats.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(stats.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stats.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquireCount, prometheus.CounterValue, float64(stats.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquireDuration, prometheus.CounterValue, stats.EmptyAcquireWaitTime().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquireCount, prometheus.CounterValue, float64(stats.CanceledAcquireCount()))
+}
+
+// CallCounter counts calls per status; database.CallStore satisfies it
+type CallCounter interface {
+	CountCallsByStatus(ctx context.Context) (map[string]int64, error)
+}
+
+// CallStatusCollector exports the number of calls in each status, read from the store at scrape time
+type CallStatusCollector struct {
+	counter  CallCounter
+	statuses []string
+	timeout  time.Duration
+	calls    *prometheus.Desc
+}
+
+// NewCallStatusCollector creates a collector over counter; each scrape's query is bounded by timeout
+// Every status in statuses is exported on each scrape, as 0 when no call has it, so emptied statuses drop to 0
+func NewCallStatusCollector(counter CallCounter, statuses []string, timeout time.Duration) *CallStatusCollector {
+	return &CallStatusCollector{
+		counter:  counter,
+		statuses: statuses,
+		timeout:  timeout,
+		calls: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "calls"),
+			"Calls in the calls table, by status.", []string{"status"}, nil),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *CallStatusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.calls
+}
+
+// Collect implements prometheus.Collector
+// A failed query is reported to Prometheus as a scrape error rather than as zero counts
+func (c *CallStatusCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	counts, err := c.counter.CountCallsByStatus(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to count calls by status", logging.Err(err))
+		ch <- prometheus.NewInvalidMetric(c.calls, err)
+		return
+	}
+
+	for _, status := range c.statuses {
+		ch <- prometheus.MustNewConstMetric(c.calls, prometheus.GaugeValue, float64(counts[status]), status)
+	}
+	// Statuses outside the known list are still reported rather than hidden
+	for status, count := range counts {
+		if !slices.Contains(c.statuses, status) {
+			ch <- prometheus.MustNewConstMetric(c.calls, prometheus.GaugeValue, float64(count), status)
+		}
+	}
+}
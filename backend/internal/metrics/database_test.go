@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// fixedCounter reports the same counts on every scrape
+type fixedCounter map[string]int64
+
+func (c fixedCounter) CountCallsByStatus(context.Context) (map[string]int64, error) {
+	return c, nil
+}
+
+func TestCallStatusCollectorReportsEveryStatus(t *testing.T) {
+	collector := NewCallStatusCollector(fixedCounter{"pending": 2, "legacy": 1}, []string{"pending", "completed"}, time.Second)
+
+	want := `
+# HELP carseller_calls Calls in the calls table, by status.
+# TYPE carseller_calls gauge
+carseller_calls{status="completed"} 0
+carseller_calls{status="legacy"} 1
+carseller_calls{status="pending"} 2
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(want)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -116,8 +116,58 @@ type Listing struct {
 
 // CarfaxResponse represents the complete response from CARFAX API
 type CarfaxResponse struct {
-	SearchArea SearchArea `json:"searchArea"`
-	Listings   []Listing  `json:"listings"`
+	SearchArea        SearchArea `json:"searchArea"`
+	Listings          []Listing  `json:"listings"`
+	TotalListingCount int        `json:"totalListingCount,omitempty"`
+	TotalPageCount    int        `json:"totalPageCount,omitempty"`
+}
+
+// SellersResponse represents the filtered listing search returned by GET /api/sellers
+type SellersResponse struct {
+	SearchArea   SearchArea `json:"searchArea"`
+	Listings     []Listing  `json:"listings"`
+	TotalFetched int        `json:"totalFetched"`
+	TotalMatched int        `json:"totalMatched"`
+	NextCursor   string     `json:"nextCursor,omitempty"`
+}
+
+// DealerListingSummary identifies the listing a dealer summary is priced from
+type DealerListingSummary struct {
+	VIN          string `json:"vin"`
+	Year         int    `json:"year"`
+	Make         string `json:"make"`
+	Model        string `json:"model"`
+	Trim         string `json:"trim"`
+	Msrp         int    `json:"msrp"`
+	CurrentPrice int    `json:"currentPrice"`
+}
+
+// DealerSummary represents one dealer and the matching vehicles it has in stock
+type DealerSummary struct {
+	CarfaxID     string                `json:"carfaxId"`
+	Name         string                `json:"name"`
+	Phone        string                `json:"phone"`
+	Address      string                `json:"address"`
+	City         string                `json:"city"`
+	State        string                `json:"state"`
+	Zip          string                `json:"zip"`
+	Rating       float64               `json:"rating"`
+	ReviewCount  int                   `json:"reviewCount"`
+	Distance     float64               `json:"distance"`
+	VehicleCount int                   `json:"vehicleCount"`
+	LowestPrice  int                   `json:"lowestPrice"`
+	MedianPrice  int                   `json:"medianPrice"`
+	BestListing  *DealerListingSummary `json:"bestListing,omitempty"`
+	VINs         []string              `json:"vins"`
+}
+
+// DealersResponse represents the dealer-grouped search returned by GET /api/dealers
+type DealersResponse struct {
+	SearchArea   SearchArea      `json:"searchArea"`
+	Dealers      []DealerSummary `json:"dealers"`
+	TotalDealers int             `json:"totalDealers"`
+	TotalFetched int             `json:"totalFetched"`
+	TotalMatched int             `json:"totalMatched"`
 }
 
 // ErrorResponse represents an error response
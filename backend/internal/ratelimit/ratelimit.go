@@ -0,0 +1,243 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Reasons a call submission is refused
+const (
+	ReasonGlobal = "global"
+	ReasonUser   = "user"
+	ReasonDaily  = "daily"
+)
+
+// sweepInterval is how often idle per-user buckets are dropped
+const sweepInterval = time.Minute
+
+// Tier holds the limits applied to one class of user
+type Tier struct {
+	// PerMinute is the sustained number of calls a user may place per minute
+	PerMinute float64
+	// Burst is how many calls a user may place at once after being idle
+	Burst int
+	// Daily is how many calls a user may place per UTC day
+	Daily int
+	// MaxBatch is the most calls a single submit may contain
+	MaxBatch int
+}
+
+// QuotaStore persists daily call counts
+type QuotaStore interface {
+	// ConsumeDailyQuota adds n calls to the user's count for the UTC day of day, unless that would exceed limit
+	ConsumeDailyQuota(ctx context.Context, userID string, day time.Time, n, limit int) (int, bool, error)
+	// RefundDailyQuota takes n calls off the user's count for the UTC day of day
+	RefundDailyQuota(ctx context.Context, userID string, day time.Time, n int) error
+}
+
+// Options configures a Limiter
+type Options struct {
+	// GlobalPerMinute and GlobalBurst bound the calls placed across all users
+	GlobalPerMinute float64
+	GlobalBurst     int
+	Tiers           map[string]Tier
+	// DefaultTier applies to users without a tier, or with a tier that is not configured
+	DefaultTier string
+}
+
+// Decision is the outcome of a Limiter check
+type Decision struct {
+	Allowed bool
+	// Reason is why the calls were refused: ReasonGlobal, ReasonUser or ReasonDaily
+	Reason string
+	// RetryAfter is how long to wait before the same submission can succeed
+	RetryAfter time.Duration
+	Tier       string
+	DailyLimit int
+	// DailyRemaining is how many more calls the user may place today
+	DailyRemaining int
+
+	// What an allowed decision took, so that Refund can give it back
+	userID string
+	calls  int
+	at     time.Time
+	global *rate.Reservation
+	user   *rate.Reservation
+}
+
+// Limiter enforces a global token bucket, a token bucket per user and a daily quota per user
+// Each call in a submission takes one token from both buckets and one unit of daily quota
+type Limiter struct {
+	store       QuotaStore
+	global      *rate.Limiter
+	tiers       map[string]Tier
+	defaultTier string
+	now         func() time.Time
+
+	mu        sync.Mutex
+	users     map[string]*rate.Limiter
+	lastSweep time.Time
+}
+
+// New creates a limiter that keeps daily counts in store
+func New(store QuotaStore, options Options) (*Limiter, error) {
+	if _, ok := options.Tiers[options.DefaultTier]; !ok {
+		return nil, fmt.Errorf("default tier %q is not configured", options.DefaultTier)
+	}
+	return &Limiter{
+		store:       store,
+		global:      rate.NewLimiter(perMinute(options.GlobalPerMinute), options.GlobalBurst),
+		tiers:       options.Tiers,
+		defaultTier: options.DefaultTier,
+		now:         time.Now,
+		users:       make(map[string]*rate.Limiter),
+	}, nil
+}
+
+// Tier resolves a tier name to its limits, falling back to the default tier
+func (l *Limiter) Tier(name string) (string, Tier) {
+	if tier, ok := l.tiers[name]; ok {
+		return name, tier
+	}
+	return l.defaultTier, l.tiers[l.defaultTier]
+}
+
+// Allow reports whether userID may place n calls now, and takes them from the user's limits if so
+// A refused submission takes nothing, so it can be retried in full after Decision.RetryAfter
+func (l *Limiter) Allow(ctx context.Context, userID, tierName string, n int) (Decision, error) {
+	tierName, tier := l.Tier(tierName)
+	decision := Decision{Tier: tierName, DailyLimit: tier.Daily}
+	now := l.now()
+
+	global := l.global.ReserveN(now, n)
+	if retryAt, ok := reserved(global, now); !ok {
+		return l.refuse(ctx, decision, userID, ReasonGlobal, retryAt, now)
+	}
+
+	user := l.userLimiter(userID, tier, now).ReserveN(now, n)
+	if retryAt, ok := reserved(user, now); !ok {
+		global.CancelAt(now)
+		return l.refuse(ctx, decision, userID, ReasonUser, retryAt, now)
+	}
+
+	used, ok, err := l.store.ConsumeDailyQuota(ctx, userID, now, n, tier.Daily)
+	if err != nil || !ok {
+		user.CancelAt(now)
+		global.CancelAt(now)
+	}
+	if err != nil {
+		return decision, fmt.Errorf("failed to consume daily quota: %w", err)
+	}
+
+	decision.DailyRemaining = max(tier.Daily-used, 0)
+	if !ok {
+		decision.Reason = ReasonDaily
+		decision.RetryAfter = untilNextDay(now)
+		return decision, nil
+	}
+
+	decision.Allowed = true
+	decision.userID = userID
+	decision.calls = n
+	decision.at = now
+	decision.global = global
+	decision.user = user
+	return decision, nil
+}
+
+// Refund gives back what an allowed decision took, for a submission that failed before any call was placed
+// The tokens return to both buckets and the calls to the daily quota of the day they were taken from
+// It returns the decision with DailyRemaining updated, which cannot be refunded again; refunding a refused decision does nothing
+func (l *Limiter) Refund(ctx context.Context, decision Decision) (Decision, error) {
+	if !decision.Allowed {
+		return decision, nil
+	}
+
+	// A reservation can only be cancelled as of the time it was made; the bucket may then be credited
+	// the refill since then a second time, which is negligible at per-minute rates
+	decision.user.CancelAt(decision.at)
+	decision.global.CancelAt(decision.at)
+	if err := l.store.RefundDailyQuota(ctx, decision.userID, decision.at, decision.calls); err != nil {
+		return decision, fmt.Errorf("failed to refund daily quota: %w", err)
+	}
+
+	decision.Allowed = false
+	decision.DailyRemaining = min(decision.DailyRemaining+decision.calls, decision.DailyLimit)
+	return decision, nil
+}
+
+// refuse completes a refusal by a token bucket with the user's remaining daily quota
+func (l *Limiter) refuse(ctx context.Context, decision Decision, userID, reason string, retryAt, now time.Time) (Decision, error) {
+	decision.Reason = reason
+	decision.RetryAfter = retryAt.Sub(now)
+
+	// Consuming nothing reads the current count without changing it
+	used, _, err := l.store.ConsumeDailyQuota(ctx, userID, now, 0, decision.DailyLimit)
+	if err != nil {
+		return decision, fmt.Errorf("failed to read daily quota: %w", err)
+	}
+	decision.DailyRemaining = max(decision.DailyLimit-used, 0)
+
+	return decision, nil
+}
+
+// userLimiter returns the user's bucket, creating it full, with the limits of their current tier
+// Buckets are keyed by user only, so a user whose tier changes keeps the tokens they have left
+// rather than starting on a fresh burst
+func (l *Limiter) userLimiter(userID string, tier Tier, now time.Time) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		// A full bucket behaves exactly like a new one, so it can be dropped
+		for key, limiter := range l.users {
+			if limiter.TokensAt(now) >= float64(limiter.Burst()) {
+				delete(l.users, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	limiter, ok := l.users[userID]
+	if !ok {
+		limiter = rate.NewLimiter(perMinute(tier.PerMinute), tier.Burst)
+		l.users[userID] = limiter
+		return limiter
+	}
+	if limit := perMinute(tier.PerMinute); limiter.Limit() != limit {
+		limiter.SetLimitAt(now, limit)
+	}
+	if limiter.Burst() != tier.Burst {
+		limiter.SetBurstAt(now, tier.Burst)
+	}
+	return limiter
+}
+
+// reserved reports whether a reservation can be honoured now, cancelling it and returning the earliest retry if not
+// A reservation larger than the bucket can never be honoured; batch limits no larger than the bursts prevent that
+func reserved(reservation *rate.Reservation, now time.Time) (time.Time, bool) {
+	if !reservation.OK() {
+		return now.Add(untilNextDay(now)), false
+	}
+	delay := reservation.DelayFrom(now)
+	if delay > 0 {
+		reservation.CancelAt(now)
+		return now.Add(delay), false
+	}
+	return now, true
+}
+
+// perMinute converts a per-minute rate to a rate.Limit
+func perMinute(calls float64) rate.Limit {
+	return rate.Limit(calls / 60)
+}
+
+// untilNextDay returns the time left until the next UTC midnight, when daily quotas reset
+func untilNextDay(now time.Time) time.Duration {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC).Sub(now)
+}
@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hackutd2025/backend/internal/database"
+)
+
+func newTestLimiter(t *testing.T, store QuotaStore) *Limiter {
+	t.Helper()
+	limiter, err := New(store, Options{
+		GlobalPerMinute: 60,
+		GlobalBurst:     10,
+		Tiers: map[string]Tier{
+			"standard": {PerMinute: 1, Burst: 5, Daily: 6, MaxBatch: 5},
+			"pro":      {PerMinute: 2, Burst: 8, Daily: 20, MaxBatch: 8},
+		},
+		DefaultTier: "standard",
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return limiter
+}
+
+func TestAllowRefusesBeyondBurst(t *testing.T) {
+	limiter := newTestLimiter(t, database.NewMemoryCallStore())
+	ctx := context.Background()
+
+	if decision, err := limiter.Allow(ctx, "alice", "", 5); err != nil || !decision.Allowed {
+		t.Fatalf("first Allow = %+v, %v; want allowed", decision, err)
+	}
+	decision, err := limiter.Allow(ctx, "alice", "", 1)
+	if err != nil || decision.Allowed || decision.Reason != ReasonUser {
+		t.Fatalf("second Allow = %+v, %v; want refused by the user bucket", decision, err)
+	}
+	if decision.DailyRemaining != 1 {
+		t.Errorf("daily remaining = %d, want 1", decision.DailyRemaining)
+	}
+}
+
+func TestTierChangeKeepsTheUsersBucket(t *testing.T) {
+	limiter := newTestLimiter(t, database.NewMemoryCallStore())
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if decision, err := limiter.Allow(ctx, "alice", "standard", 5); err != nil || !decision.Allowed {
+		t.Fatalf("standard Allow = %+v, %v; want allowed", decision, err)
+	}
+
+	// Moving to a tier with a larger burst does not refill the bucket the user just emptied
+	decision, err := limiter.Allow(ctx, "alice", "pro", 1)
+	if err != nil || decision.Allowed || decision.Reason != ReasonUser {
+		t.Fatalf("pro Allow = %+v, %v; want refused by the user bucket", decision, err)
+	}
+	if decision.Tier != "pro" || decision.DailyLimit != 20 {
+		t.Errorf("decision tier = %q with daily limit %d, want pro with 20", decision.Tier, decision.DailyLimit)
+	}
+
+	// It refills at the new tier's rate, up to the new tier's burst
+	now = now.Add(4 * time.Minute)
+	if decision, err := limiter.Allow(ctx, "alice", "pro", 8); err != nil || !decision.Allowed {
+		t.Fatalf("pro Allow after refill = %+v, %v; want allowed", decision, err)
+	}
+}
+
+func TestRefundReturnsTokensAndQuota(t *testing.T) {
+	store := database.NewMemoryCallStore()
+	limiter := newTestLimiter(t, store)
+	ctx := context.Background()
+
+	decision, err := limiter.Allow(ctx, "alice", "", 5)
+	if err != nil || !decision.Allowed {
+		t.Fatalf("Allow = %+v, %v; want allowed", decision, err)
+	}
+
+	refunded, err := limiter.Refund(ctx, decision)
+	if err != nil {
+		t.Fatalf("Refund: %v", err)
+	}
+	if refunded.DailyRemaining != 6 {
+		t.Errorf("daily remaining after refund = %d, want 6", refunded.DailyRemaining)
+	}
+	// A second refund of the same decision gives nothing back
+	if _, err := limiter.Refund(ctx, refunded); err != nil {
+		t.Fatalf("second Refund: %v", err)
+	}
+
+	// The whole burst is available again, and the daily count was not charged
+	if decision, err := limiter.Allow(ctx, "alice", "", 5); err != nil || !decision.Allowed {
+		t.Fatalf("Allow after refund = %+v, %v; want allowed", decision, err)
+	}
+	used, _, err := store.ConsumeDailyQuota(ctx, "alice", time.Now(), 0, 6)
+	if err != nil || used != 5 {
+		t.Errorf("daily count = %d, %v; want 5", used, err)
+	}
+}
@@ -0,0 +1,130 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies spans created by this module
+const instrumentationName = "hackutd2025/backend"
+
+// Options configures trace export
+type Options struct {
+	// Exporter is "none", "stdout" or "otlp"
+	Exporter string
+	// OTLPEndpoint overrides OTEL_EXPORTER_OTLP_ENDPOINT for the otlp exporter
+	OTLPEndpoint string
+	ServiceName  string
+	// SampleRatio is the fraction of new traces recorded; incoming sampled traces are always recorded
+	SampleRatio float64
+}
+
+// Setup installs the global tracer provider and W3C trace-context propagation
+// The returned function flushes and stops the exporter; it is a no-op when tracing is disabled
+func Setup(ctx context.Context, options Options) (func(context.Context) error, error) {
+	// Propagate trace context even when spans are not exported, so upstream traces stay connected
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch options.Exporter {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "otlp":
+		var exporterOptions []otlptracehttp.Option
+		if options.OTLPEndpoint != "" {
+			exporterOptions = append(exporterOptions, otlptracehttp.WithEndpointURL(options.OTLPEndpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, exporterOptions...)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q (expected none, stdout or otlp)", options.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", options.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(options.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(options.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer for spans created by this module
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Handler wraps the whole server so every request gets a server span, continuing any incoming trace
+func Handler(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "http.server")
+}
+
+// RouteMiddleware names the server span after the matched mux route template
+func RouteMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				span := trace.SpanFromContext(r.Context())
+				span.SetName(r.Method + " " + template)
+				span.SetAttributes(semconv.HTTPRoute(template))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// NewTransport wraps base, or http.DefaultTransport when base is nil, with client spans
+// and W3C traceparent injection on every outbound request
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return otelhttp.NewTransport(base)
+}
+
+// TraceParent returns the W3C traceparent of the span in ctx, or "" if there is none
+func TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier.Get("traceparent")
+}
+
+// LinkTraceParent links the span in ctx to the span identified by a stored W3C traceparent
+// It reports whether the traceparent was valid
+func LinkTraceParent(ctx context.Context, traceParent string, attrs ...attribute.KeyValue) bool {
+	linked := propagation.TraceContext{}.Extract(context.Background(), propagation.MapCarrier{"traceparent": traceParent})
+	spanContext := trace.SpanContextFromContext(linked)
+	if !spanContext.IsValid() {
+		return false
+	}
+
+	trace.SpanFromContext(ctx).AddLink(trace.Link{SpanContext: spanContext, Attributes: attrs})
+	return true
+}
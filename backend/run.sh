@@ -6,7 +6,7 @@ echo "🚀 Starting Car Seller API Backend..."
 
 # Check if Go is installed
 if ! command -v go &> /dev/null; then
-    echo "❌ Error: Go is not installed. Please install Go 1.21 or higher."
+    echo "❌ Error: Go is not installed. Please install Go 1.25 or higher."
     exit 1
 fi
 
@@ -263,6 +263,8 @@ export const initiateBatchCalls = action({
   args: {
     sessionId: v.id("sessions"),
     listingIds: v.array(v.id("listings")),
+    // Auth0 access token of the signed-in user; the backend places calls on their behalf
+    accessToken: v.string(),
   },
   handler: async (
     ctx,
@@ -322,6 +324,7 @@ export const initiateBatchCalls = action({
         method: "POST",
         headers: {
           "Content-Type": "application/json",
+          Authorization: `Bearer ${args.accessToken}`,
         },
         body: JSON.stringify(dealerQueries),
       });
@@ -1,5 +1,6 @@
 import { useState, useMemo, useEffect } from "react";
 import { useMutation, useAction } from "convex/react";
+import { useAuth0 } from "@auth0/auth0-react";
 import { api } from "../../../convex/_generated/api";
 import type { Id } from "../../../convex/_generated/dataModel";
 import { Phone, Scale, CheckCircle2, AlertCircle, Clock, Loader2, PhoneCall, FileText, MapPin, ExternalLink } from "lucide-react";
@@ -37,7 +38,18 @@ interface BackendCall {
   phone_number?: string;
   msrp?: number;
   listing_price?: number;
-  status?: "pending" | "completed" | "failed";
+  status?:
+    | "pending"
+    | "dispatched"
+    | "dispatch_failed"
+    | "ringing"
+    | "in_progress"
+    | "completed"
+    | "unavailable"
+    | "no_answer"
+    | "voicemail"
+    | "failed"
+    | "cancelled";
   is_available?: boolean;
   deal_price?: number;
   remarks?: string;
@@ -87,13 +99,17 @@ export function ListingsTable({ listings, sessionId }: ListingsTableProps) {
 
   const toggleSelect = useMutation(api.listings.toggleSelect);
   const initiateBatchCalls = useAction(api.actions.initiateBatchCalls);
+  const { getAccessTokenSilently } = useAuth0();
 
   // Fetch call data from backend API
   const fetchCallData = async () => {
     try {
+      // The backend only returns calls belonging to the signed-in user
+      const accessToken = await getAccessTokenSilently();
       const response = await fetch(`http://localhost:8080/api/calls`, {
         headers: {
           "Content-Type": "application/json",
+          Authorization: `Bearer ${accessToken}`,
         },
       });
       if (!response.ok) {
@@ -144,12 +160,24 @@ export function ListingsTable({ listings, sessionId }: ListingsTableProps) {
         let callStatus: Listing["callStatus"];
         switch (backendCall.status) {
           case "pending":
+            callStatus = "queued";
+            break;
+          case "dispatched":
+          case "ringing":
             callStatus = "dialing";
             break;
+          case "in_progress":
+            callStatus = "connected";
+            break;
           case "completed":
             callStatus = "completed";
             break;
+          case "unavailable":
+          case "no_answer":
+          case "voicemail":
           case "failed":
+          case "dispatch_failed":
+          case "cancelled":
             callStatus = "failed";
             break;
           default:
@@ -229,6 +257,7 @@ export function ListingsTable({ listings, sessionId }: ListingsTableProps) {
       const result = await initiateBatchCalls({
         sessionId,
         listingIds: selectedListings.map((l) => l._id),
+        accessToken: await getAccessTokenSilently(),
       });
 
       toast.success(